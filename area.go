@@ -0,0 +1,142 @@
+package gobiomes
+
+import (
+	"context"
+)
+
+// SearchArea 描述 block 坐标下的搜索区域。
+type SearchArea interface {
+	// Bounds 返回区域的包围矩形（含边界）。
+	Bounds() (minX, minZ, maxX, maxZ int)
+	// Contains 判断 block 坐标 (x, z) 是否在区域内。
+	Contains(x, z int) bool
+}
+
+// RectArea 是矩形区域 [MinX, MaxX] x [MinZ, MaxZ]。
+type RectArea struct {
+	MinX, MinZ, MaxX, MaxZ int
+}
+
+func (a RectArea) Bounds() (int, int, int, int) {
+	return min(a.MinX, a.MaxX), min(a.MinZ, a.MaxZ), max(a.MinX, a.MaxX), max(a.MinZ, a.MaxZ)
+}
+
+func (a RectArea) Contains(x, z int) bool {
+	x0, z0, x1, z1 := a.Bounds()
+	return x >= x0 && x <= x1 && z >= z0 && z <= z1
+}
+
+// CircleArea 是以 Center 为圆心、Radius 为半径的圆形区域（含边界）。
+type CircleArea struct {
+	Center Pos
+	Radius int
+}
+
+func (a CircleArea) Bounds() (int, int, int, int) {
+	return a.Center.X - a.Radius, a.Center.Z - a.Radius, a.Center.X + a.Radius, a.Center.Z + a.Radius
+}
+
+func (a CircleArea) Contains(x, z int) bool {
+	dx, dz := int64(x-a.Center.X), int64(z-a.Center.Z)
+	return dx*dx+dz*dz <= int64(a.Radius)*int64(a.Radius)
+}
+
+// AnnulusArea 是以 Center 为圆心、距离在 [Inner, Outer] 内的环形区域。
+type AnnulusArea struct {
+	Center       Pos
+	Inner, Outer int
+}
+
+func (a AnnulusArea) Bounds() (int, int, int, int) {
+	return CircleArea{a.Center, a.Outer}.Bounds()
+}
+
+func (a AnnulusArea) Contains(x, z int) bool {
+	dx, dz := int64(x-a.Center.X), int64(z-a.Center.Z)
+	d := dx*dx + dz*dz
+	return d >= int64(a.Inner)*int64(a.Inner) && d <= int64(a.Outer)*int64(a.Outer)
+}
+
+// PolygonArea 是由顶点 Points 依次连接围成的多边形区域，按奇偶规则判断内外。
+type PolygonArea struct {
+	Points []Pos
+}
+
+func (a PolygonArea) Bounds() (int, int, int, int) {
+	if len(a.Points) == 0 {
+		return 0, 0, -1, -1
+	}
+	x0, z0 := a.Points[0].X, a.Points[0].Z
+	x1, z1 := x0, z0
+	for _, p := range a.Points[1:] {
+		x0, x1 = min(x0, p.X), max(x1, p.X)
+		z0, z1 = min(z0, p.Z), max(z1, p.Z)
+	}
+	return x0, z0, x1, z1
+}
+
+func (a PolygonArea) Contains(x, z int) bool {
+	in := false
+	n := len(a.Points)
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		pi, pj := a.Points[i], a.Points[j]
+		if (pi.Z > z) != (pj.Z > z) {
+			// 交点 X 坐标: pi.X + (z-pi.Z)*(pj.X-pi.X)/(pj.Z-pi.Z)，移项避免除法
+			lhs := int64(x-pi.X) * int64(pj.Z-pi.Z)
+			rhs := int64(z-pi.Z) * int64(pj.X-pi.X)
+			if pj.Z-pi.Z > 0 && lhs < rhs || pj.Z-pi.Z < 0 && lhs > rhs {
+				in = !in
+			}
+		}
+	}
+	return in
+}
+
+// RegionBounds 返回结构在 area 的包围矩形内可能出现生成尝试的 region 范围（含两端）。
+func (f *Finder) RegionBounds(st StructureType, area SearchArea) (rx0, rz0, rx1, rz1 int, err error) {
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return 0, 0, 0, 0, err
+	}
+	rb := config.RegionSize * 16
+	x0, z0, x1, z1 := area.Bounds()
+	return floorDiv(x0, rb), floorDiv(z0, rb), floorDiv(x1, rb), floorDiv(z1, rb), nil
+}
+
+// ForEachStructure 依次对 area 内结构 st 的每个生成尝试位置调用 fn（按 region 行优先顺序），
+// fn 返回 false 时停止。不检查生物群系，需要时在 fn 中调用 IsViableStructurePos。
+// ctx 被取消时停止并返回 ctx.Err()。
+func (f *Finder) ForEachStructure(ctx context.Context, st StructureType, seed uint64, area SearchArea, fn func(p Pos) bool) error {
+	rx0, rz0, rx1, rz1, err := f.RegionBounds(st, area)
+	if err != nil {
+		return err
+	}
+	for rz := rz0; rz <= rz1; rz++ {
+		for rx := rx0; rx <= rx1; rx++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			p, err := f.GetStructurePos(st, seed, rx, rz)
+			if err != nil {
+				return err
+			}
+			if p == nil || !area.Contains(p.X, p.Z) {
+				continue
+			}
+			if !fn(*p) {
+				return nil
+			}
+		}
+	}
+	return nil
+}
+
+// FindStructures 返回 area 内结构 st 的所有生成尝试位置。
+func (f *Finder) FindStructures(ctx context.Context, st StructureType, seed uint64, area SearchArea) ([]Pos, error) {
+	var out []Pos
+	err := f.ForEachStructure(ctx, st, seed, area, func(p Pos) bool {
+		out = append(out, p)
+		return true
+	})
+	return out, err
+}
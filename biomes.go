@@ -1,5 +1,7 @@
 package gobiomes
 
+import "strings"
+
 // Biome 生物群系类型
 type Biome int
 
@@ -305,3 +307,64 @@ func GetMutated(mc int, id Biome) Biome {
 		return None
 	}
 }
+
+// biomeIDs 把资源 ID（不含 "minecraft:"）映射到生物群系常量，包含 1.18 前后的名称。
+var biomeIDs = map[string]Biome{
+	"ocean": Ocean, "plains": Plains, "desert": Desert, "mountains": Mountains, "forest": Forest,
+	"taiga": Taiga, "swamp": Swamp, "river": River, "nether_wastes": NetherWastes, "nether": NetherWastes,
+	"the_end": TheEnd, "frozen_ocean": FrozenOcean, "frozen_river": FrozenRiver, "snowy_tundra": SnowyTundra,
+	"snowy_mountains": SnowyMountains, "mushroom_fields": MushroomFields, "mushroom_field_shore": MushroomFieldShore,
+	"beach": Beach, "desert_hills": DesertHills, "wooded_hills": WoodedHills, "taiga_hills": TaigaHills,
+	"mountain_edge": MountainEdge, "jungle": Jungle, "jungle_hills": JungleHills, "jungle_edge": JungleEdge,
+	"deep_ocean": DeepOcean, "stone_shore": StoneShore, "snowy_beach": SnowyBeach, "birch_forest": BirchForest,
+	"birch_forest_hills": BirchForestHills, "dark_forest": DarkForest, "snowy_taiga": SnowyTaiga,
+	"snowy_taiga_hills": SnowyTaigaHills, "giant_tree_taiga": GiantTreeTaiga, "giant_tree_taiga_hills": GiantTreeTaigaHills,
+	"wooded_mountains": WoodedMountains, "savanna": Savanna, "savanna_plateau": SavannaPlateau, "badlands": Badlands,
+	"wooded_badlands_plateau": WoodedBadlandsPlateau, "badlands_plateau": BadlandsPlateau,
+	"small_end_islands": SmallEndIslands, "end_midlands": EndMidlands, "end_highlands": EndHighlands,
+	"end_barrens": EndBarrens, "warm_ocean": WarmOcean, "lukewarm_ocean": LukewarmOcean, "cold_ocean": ColdOcean,
+	"deep_warm_ocean": DeepWarmOcean, "deep_lukewarm_ocean": DeepLukewarmOcean, "deep_cold_ocean": DeepColdOcean,
+	"deep_frozen_ocean": DeepFrozenOcean, "the_void": TheVoid, "sunflower_plains": SunflowerPlains,
+	"desert_lakes": DesertLakes, "gravelly_mountains": GravellyMountains, "flower_forest": FlowerForest,
+	"taiga_mountains": TaigaMountains, "swamp_hills": SwampHills, "ice_spikes": IceSpikes,
+	"modified_jungle": ModifiedJungle, "modified_jungle_edge": ModifiedJungleEdge, "tall_birch_forest": TallBirchForest,
+	"tall_birch_hills": TallBirchHills, "dark_forest_hills": DarkForestHills, "snowy_taiga_mountains": SnowyTaigaMountains,
+	"giant_spruce_taiga": GiantSpruceTaiga, "giant_spruce_taiga_hills": GiantSpruceTaigaHills,
+	"modified_gravelly_mountains": ModifiedGravellyMountains, "shattered_savanna": ShatteredSavanna,
+	"shattered_savanna_plateau": ShatteredSavannaPlateau, "eroded_badlands": ErodedBadlands,
+	"modified_wooded_badlands_plateau": ModifiedWoodedBadlandsPlateau, "modified_badlands_plateau": ModifiedBadlandsPlateau,
+	"bamboo_jungle": BambooJungle, "bamboo_jungle_hills": BambooJungleHills, "soul_sand_valley": SoulSandValley,
+	"crimson_forest": CrimsonForest, "warped_forest": WarpedForest, "basalt_deltas": BasaltDeltas,
+	"dripstone_caves": DripstoneCaves, "lush_caves": LushCaves, "meadow": Meadow, "grove": Grove,
+	"snowy_slopes": SnowySlopes, "jagged_peaks": JaggedPeaks, "frozen_peaks": FrozenPeaks, "stony_peaks": StonyPeaks,
+	"old_growth_birch_forest": OldGrowthBirchForest, "old_growth_pine_taiga": OldGrowthPineTaiga,
+	"old_growth_spruce_taiga": OldGrowthSpruceTaiga, "snowy_plains": SnowyPlains, "sparse_jungle": SparseJungle,
+	"stony_shore": StonyShore, "windswept_hills": WindsweptHills, "windswept_forest": WindsweptForest,
+	"windswept_gravelly_hills": WindsweptGravellyHills, "windswept_savanna": WindsweptSavanna,
+	"wooded_badlands": WoodedBadlands, "deep_dark": DeepDark, "mangrove_swamp": MangroveSwamp,
+	"cherry_grove": CherryGrove, "pale_garden": PaleGarden,
+}
+
+// BiomeFromID 返回资源 ID（例如 "minecraft:plains" 或 "plains"）对应的生物群系。
+func BiomeFromID(id string) (Biome, bool) {
+	name, ok := strings.CutPrefix(id, "minecraft:")
+	if !ok && strings.Contains(id, ":") {
+		return None, false
+	}
+	b, ok := biomeIDs[name]
+	if !ok {
+		return None, false
+	}
+	return b, true
+}
+
+// biomeDimension 返回生物群系所在的维度。
+func biomeDimension(b Biome) Dimension {
+	switch b {
+	case NetherWastes, SoulSandValley, CrimsonForest, WarpedForest, BasaltDeltas:
+		return DimNether
+	case TheEnd, SmallEndIslands, EndMidlands, EndHighlands, EndBarrens:
+		return DimEnd
+	}
+	return DimOverworld
+}
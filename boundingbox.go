@@ -0,0 +1,170 @@
+package gobiomes
+
+import (
+	"fmt"
+)
+
+// makeBoundingBox 对应 StructurePiece.makeBoundingBox：
+// 朝向为南北 (rotation 0/2) 时 width 沿 X，否则 width 沿 Z。
+func makeBoundingBox(x, y, z, rotation, width, height, depth int) BoundingBox {
+	if rotation&1 == 0 {
+		return BoundingBox{x, y, z, x + width - 1, y + height - 1, z + depth - 1}
+	}
+	return BoundingBox{x, y, z, x + depth - 1, y + height - 1, z + width - 1}
+}
+
+// dimHeightRange 返回维度在该版本下的建筑高度范围。
+func dimHeightRange(mc int, dim Dimension) (int, int) {
+	switch {
+	case dim == DimNether:
+		return 0, 127
+	case dim == DimOverworld && mc >= MC_1_18:
+		return -64, 319
+	}
+	return 0, 255
+}
+
+// structureExtent 返回尺寸取决于部件组装的结构相对起点的最大延伸距离。
+func structureExtent(mc int, st StructureType) int {
+	switch st {
+	case AncientCity, TrialChambers:
+		return 116
+	case Village, Outpost, Bastion, TrailRuins:
+		return 80
+	case Mansion:
+		return 88
+	case Stronghold:
+		return 112
+	case OceanRuin:
+		return 32
+	case RuinedPortal, RuinedPortalN:
+		return 24
+	case Geode:
+		return 12
+	}
+	return 0
+}
+
+// GetBoundingBox 返回结构在 pos（GetStructurePos 返回的生成尝试位置）处的 block 包围盒。
+// biome 为 pos 处的生物群系，用于确定变种。
+//
+// 女巫小屋、神庙、雪屋、海底神殿、沉船的包围盒由结构 RNG 选出的旋转和模板唯一确定；
+// 下界要塞、要塞、废弃矿井和末地城由部件生成得到精确包围盒。依赖地形的 Y 坐标使用原版的初始高度
+// （例如神庙的 y=64，末地城为 GetStructureY 的估计值），实际高度可能被地形调整。
+//
+// 第二个返回值为 true 表示返回的只是结构可能占据的范围，而不是结构的包围盒：
+// 拼图结构、林地府邸、废弃传送门和紫水晶晶洞返回起点附近按最大组装距离估计的范围，
+// 起点高度已知时（远古城市、堡垒遗迹、试炼密室、紫水晶晶洞）Y 范围以起点高度为中心，否则为整个建筑高度；
+// 宝藏返回箱子所在的竖直方块列，沙漠水井返回水平范围精确、Y 为整个建筑高度的范围。拼图结构的实际包围盒可以用 GetJigsawPieces 和 JigsawBoundingBox 得到。
+func (f *Finder) GetBoundingBox(st StructureType, seed uint64, pos Pos, biome Biome) (*BoundingBox, bool, error) {
+	mc := f.Version
+	if st == Stronghold {
+		// 要塞不是基于 region 的结构，没有 StructureConfig
+		bb := PiecesBoundingBox(f.GetStrongholdPieces(seed, pos.X>>4, pos.Z>>4))
+		return &bb, false, nil
+	}
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return nil, false, err
+	}
+	r := &Rng{seed: f.ChunkGenerateRnd(seed, pos.X>>4, pos.Z>>4)}
+	var bb BoundingBox
+
+	switch st {
+	case DesertPyramid:
+		bb = makeBoundingBox(pos.X, 64, pos.Z, r.NextInt(4), 21, 15, 21)
+	case JunglePyramid:
+		bb = makeBoundingBox(pos.X, 64, pos.Z, r.NextInt(4), 12, 10, 15)
+	case SwampHut:
+		bb = makeBoundingBox(pos.X, 64, pos.Z, r.NextInt(4), 7, 7, 9)
+
+	case Igloo:
+		if mc <= MC_1_12 {
+			bb = makeBoundingBox(pos.X, 64, pos.Z, r.NextInt(4), 7, 5, 8)
+			break
+		}
+		v, err := f.GetVariant(Igloo, seed, pos.X, pos.Z, biome)
+		if err != nil {
+			return nil, false, err
+		}
+		bb = PiecesBoundingBox(iglooPieces(pos.X, pos.Z, v))
+
+	case Monument:
+		x, z := pos.X-29, pos.Z-29
+		if mc <= MC_1_12 {
+			x += 8
+			z += 8
+		}
+		bb = BoundingBox{x, 39, z, x + 57, 61, z + 57}
+
+	case Shipwreck:
+		v, err := f.GetVariant(Shipwreck, seed, pos.X, pos.Z, biome)
+		if err != nil {
+			return nil, false, err
+		}
+		bb = BoundingBox{pos.X + v.X, 90, pos.Z + v.Z, pos.X + v.X + v.SX - 1, 90 + v.SY - 1, pos.Z + v.Z + v.SZ - 1}
+
+	case Treasure:
+		y0, y1 := dimHeightRange(mc, config.Dim)
+		bb = BoundingBox{pos.X, y0, pos.Z, pos.X, y1, pos.Z}
+		return &bb, true, nil
+
+	case DesertWell:
+		// 水井放在地表，高度只能由地形确定
+		y0, y1 := dimHeightRange(mc, config.Dim)
+		bb = BoundingBox{pos.X - 2, y0, pos.Z - 2, pos.X + 2, y1, pos.Z + 2}
+		return &bb, true, nil
+
+	case EndCity:
+		y, _, err := f.GetStructureY(st, seed, pos, biome)
+		if err != nil {
+			return nil, false, err
+		}
+		bb = PiecesBoundingBox(f.GetEndCityPieces(seed, pos.X>>4, pos.Z>>4, y))
+
+	case Fortress:
+		bb = PiecesBoundingBox(f.GetFortressPieces(seed, pos.X>>4, pos.Z>>4))
+
+	case Mineshaft:
+		bb = PiecesBoundingBox(f.GetMineshaftPieces(seed, pos.X>>4, pos.Z>>4, IsMineshaftMesa(mc, biome)))
+
+	case Feature:
+		t, ok := ResolveFeatureType(mc, biome)
+		if !ok {
+			return nil, false, fmt.Errorf("Feature generates no temple in biome %v", biome)
+		}
+		return f.GetBoundingBox(t, seed, pos, biome)
+
+	default:
+		d := structureExtent(mc, st)
+		if d == 0 {
+			return nil, false, fmt.Errorf("GetBoundingBox not implemented for %v", st)
+		}
+		y0, y1 := dimHeightRange(mc, config.Dim)
+		switch st {
+		case AncientCity, Bastion, TrialChambers, Geode:
+			y, _, err := f.GetStructureY(st, seed, pos, biome)
+			if err != nil {
+				return nil, false, err
+			}
+			y0, y1 = max(y0, y-d), min(y1, y+d)
+		}
+		bb = BoundingBox{pos.X - d, y0, pos.Z - d, pos.X + d, y1, pos.Z + d}
+		return &bb, true, nil
+	}
+	return &bb, false, nil
+}
+
+// GetBoundingBox 返回结构在 pos 处的 block 包围盒，使用生成器的种子和 pos 处的生物群系，返回值含义同 Finder.GetBoundingBox。
+func (gen *Generator) GetBoundingBox(st StructureType, pos Pos) (*BoundingBox, bool, error) {
+	f := NewFinder(gen.Version)
+	if st == Feature {
+		t, ok := gen.ResolveFeature(pos.X, pos.Z)
+		if !ok {
+			return nil, false, fmt.Errorf("Feature generates no temple at %v", pos)
+		}
+		st = t
+	}
+	biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
+	return f.GetBoundingBox(st, gen.Seed, pos, biome)
+}
@@ -2,7 +2,6 @@ package main
 
 import (
 	"bufio"
-	"context"
 	"fmt"
 	"math"
 	"os"
@@ -120,6 +119,24 @@ func doSearch(mc int, seed uint64, structID gobiomes.StructureType, mode int, ra
 		return
 	}
 
+	if mode == 1 {
+		// 最近的一个：逐环向外搜索，保证返回的是最近的结构
+		gen := gobiomes.NewGenerator(mc, 0)
+		gen.ApplySeed(seed, gobiomes.DimOverworld)
+		p, err := gen.Locate(structID, gobiomes.Pos{}, radius)
+		if err != nil {
+			fmt.Printf("错误: %v\n", err)
+			return
+		}
+		if p == nil {
+			fmt.Println("未找到结构。")
+			return
+		}
+		fmt.Printf("找到最近的结构: x=%d, z=%d (距离中心: %.1f, 耗时: %v)\n", p.X, p.Z,
+			math.Sqrt(float64(p.X)*float64(p.X)+float64(p.Z)*float64(p.Z)), time.Since(startTime).Truncate(time.Millisecond))
+		return
+	}
+
 	regionSize := int(sc.RegionSize) * 16
 	r := (radius + regionSize - 1) / regionSize
 	if r < 0 {
@@ -150,8 +167,6 @@ func doSearch(mc int, seed uint64, structID gobiomes.StructureType, mode int, ra
 
 	// 进度条显示
 	stopProgress := make(chan struct{})
-	ctx, cancel := context.WithCancel(context.Background())
-	defer cancel()
 
 	go func() {
 		ticker := time.NewTicker(200 * time.Millisecond)
@@ -180,29 +195,17 @@ func doSearch(mc int, seed uint64, structID gobiomes.StructureType, mode int, ra
 			wGen := gobiomes.NewGenerator(mc, 0)
 			wGen.ApplySeed(seed, gobiomes.DimOverworld)
 
-			for {
-				select {
-				case <-ctx.Done():
-					return
-				case t, ok := <-taskChan:
-					if !ok {
-						return
-					}
-					p, err := wFinder.GetStructurePos(structID, seed, t.rx, t.rz)
-					if err == nil && p != nil {
-						distSq := int64(p.X)*int64(p.X) + int64(p.Z)*int64(p.Z)
-						if distSq <= int64(radius)*int64(radius) {
-							if wGen.IsViableStructurePos(structID, p.X, p.Z, 0) {
-								foundChan <- pos{p.X, p.Z}
-								if mode == 1 {
-									cancel() // 找到一个就停止
-									return
-								}
-							}
+			for t := range taskChan {
+				p, err := wFinder.GetStructurePos(structID, seed, t.rx, t.rz)
+				if err == nil && p != nil {
+					distSq := int64(p.X)*int64(p.X) + int64(p.Z)*int64(p.Z)
+					if distSq <= int64(radius)*int64(radius) {
+						if wGen.IsViableStructurePos(structID, p.X, p.Z, 0) {
+							foundChan <- pos{p.X, p.Z}
 						}
 					}
-					atomic.AddInt64(&regionsDone, 1)
 				}
+				atomic.AddInt64(&regionsDone, 1)
 			}
 		}()
 	}
@@ -230,12 +233,6 @@ func doSearch(mc int, seed uint64, structID gobiomes.StructureType, mode int, ra
 		return di < dj
 	})
 
-	if mode == 1 {
-		p := found[0]
-		fmt.Printf("找到最近的结构: x=%d, z=%d (距离中心: %.1f)\n", p.x, p.z, math.Sqrt(float64(p.x*p.x+p.z*p.z)))
-		return
-	}
-
 	// 搜索多联
 	var clusters []cluster
 
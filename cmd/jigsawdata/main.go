@@ -0,0 +1,69 @@
+// jigsawdata 从游戏 jar、数据包或数据生成器的输出中提取拼图结构元数据，写成 gobiomes.DefaultJigsawData
+// 嵌入的 JSON 文件。后面的参数覆盖前面的参数中同名的资源。
+//
+//	go run ./cmd/jigsawdata -o jigsawdata/1.21.1.json ~/.minecraft/versions/1.21.1/1.21.1.jar
+//	go run ./cmd/jigsawdata -o jigsawdata/1.18.2.json 1.18.2.jar generated
+//
+// 1.16.2 至 1.18.2 的模板池只在数据生成器的输出（java -DbundlerMainClass=net.minecraft.data.Main -jar server.jar --reports）
+// 中给出，需要同时传入 jar 和生成的 generated 目录。把生成的文件放到 jigsawdata 目录并在
+// jigsawDataVersions 中登记后即可随包嵌入。找不到的模板会给出警告并按空模板处理。
+package main
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"gobiomes"
+)
+
+func main() {
+	out := flag.String("o", "", "输出的 JSON 文件")
+	flag.Parse()
+	if *out == "" || flag.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "用法: jigsawdata -o <输出.json> <jar 或目录>...")
+		os.Exit(2)
+	}
+
+	var sources []fs.FS
+	for _, path := range flag.Args() {
+		info, err := os.Stat(path)
+		if err != nil {
+			fatal(err)
+		}
+		if info.IsDir() {
+			sources = append(sources, os.DirFS(path))
+			continue
+		}
+		jar, err := zip.OpenReader(path)
+		if err != nil {
+			fatal(err)
+		}
+		defer jar.Close()
+		sources = append(sources, jar)
+	}
+
+	data, err := gobiomes.LoadJigsawData(sources...)
+	if err != nil {
+		fatal(err)
+	}
+	for _, loc := range data.Missing {
+		fmt.Fprintf(os.Stderr, "jigsawdata: 警告: 找不到模板 %s，按空模板处理\n", loc)
+	}
+	b, err := json.Marshal(data)
+	if err != nil {
+		fatal(err)
+	}
+	if err := os.WriteFile(*out, b, 0o644); err != nil {
+		fatal(err)
+	}
+	fmt.Printf("%d 个模板池, %d 个模板, %d 个结构 -> %s\n", len(data.Pools), len(data.Templates), len(data.Structures), *out)
+}
+
+func fatal(err error) {
+	fmt.Fprintln(os.Stderr, "jigsawdata:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,244 @@
+package gobiomes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// StructureObservation 是一次观察到的结构：结构类型及其生成尝试位置附近的 block 坐标。
+// Tolerance 是位置误差（chunk），例如只知道结构大致位置时可以设为 1~2。
+type StructureObservation struct {
+	Type      StructureType
+	Pos       Pos
+	Tolerance int
+}
+
+// crackObs 是预处理后的观察：所在 region 以及 region 内 chunk 偏移的允许范围（含两端）。
+type crackObs struct {
+	st         StructureType
+	config     StructureConfig
+	regX, regZ int
+	x0, x1     int
+	z0, z1     int
+	linear     bool // 偏移由 getFeatureChunkInRegion 计算，可用于低位筛选和快速检查
+}
+
+func (f *Finder) prepareCrack(obs []StructureObservation) ([]crackObs, error) {
+	if len(obs) == 0 {
+		return nil, fmt.Errorf("no structure observations")
+	}
+	out := make([]crackObs, 0, len(obs))
+	for _, o := range obs {
+		config, err := f.GetStructureConfig(o.Type)
+		if err != nil {
+			return nil, err
+		}
+		if config.RegionSize <= 1 {
+			return nil, fmt.Errorf("structure %v is not region based", o.Type)
+		}
+		cx, cz := floorDiv(o.Pos.X, 16), floorDiv(o.Pos.Z, 16)
+		c := crackObs{
+			st:     o.Type,
+			config: config,
+			regX:   floorDiv(cx, config.RegionSize),
+			regZ:   floorDiv(cz, config.RegionSize),
+			linear: f.isLinearPlacement(o.Type),
+		}
+		bx, bz := c.regX*config.RegionSize, c.regZ*config.RegionSize
+		c.x0, c.x1 = max(cx-o.Tolerance-bx, 0), min(cx+o.Tolerance-bx, config.ChunkRange-1)
+		c.z0, c.z1 = max(cz-o.Tolerance-bz, 0), min(cz+o.Tolerance-bz, config.ChunkRange-1)
+		if c.x0 > c.x1 || c.z0 > c.z1 {
+			return nil, fmt.Errorf("structure %v cannot generate at %v", o.Type, o.Pos)
+		}
+		out = append(out, c)
+	}
+	return out, nil
+}
+
+// isLinearPlacement 判断结构在该版本的 region 内偏移是否为 getFeatureChunkInRegion 的单次 nextInt。
+func (f *Finder) isLinearPlacement(st StructureType) bool {
+	switch st {
+	case Feature, DesertPyramid, JunglePyramid, SwampHut,
+		Igloo, Village, OceanRuin, Shipwreck,
+		RuinedPortal, RuinedPortalN, AncientCity,
+		TrailRuins, TrialChambers, Outpost:
+		return true
+	case Monument, Fortress, Bastion:
+		return f.Version >= MC_1_18
+	}
+	if cs, ok := GetCustomStructure(st); ok {
+		return cs.Spread == SpreadLinear
+	}
+	return false
+}
+
+// lowModulus 返回观察参与低 20 位筛选时使用的模数 g = min(ChunkRange 的 2 的幂因子, 8)，
+// 不能参与筛选（不是线性放置，或 ChunkRange 为奇数或 2 的幂）时返回 0。
+func (c *crackObs) lowModulus() int {
+	cr := c.config.ChunkRange
+	if !c.linear || cr&1 != 0 || cr&(cr-1) == 0 {
+		return 0
+	}
+	return min(cr&-cr, 8)
+}
+
+// constrainsLow 判断观察能否排除一部分低 20 位：参与低位筛选，且至少一个方向的偏移范围窄于 g。
+func (c *crackObs) constrainsLow() bool {
+	g := c.lowModulus()
+	return g != 0 && (c.x1-c.x0+1 < g || c.z1-c.z0+1 < g)
+}
+
+// lowOK 用种子的低 20 位检查观察：ChunkRange 含因子 g (2, 4 或 8) 时，偏移对 g 取模的值只取决于低 20 位。
+func (c *crackObs) lowOK(low uint64) bool {
+	const mask20 = (1 << 20) - 1
+	g := c.lowModulus()
+	if g == 0 {
+		return true
+	}
+	s := (low + uint64(c.regX)*341873128712 + uint64(c.regZ)*132897987541 + uint64(c.config.Salt)) & mask20
+	s = (s ^ 0x5deece66d) & mask20
+	s = (s*0x5deece66d + 0xb) & mask20
+	if !hasResidue(c.x0, c.x1, int(s>>17)&(g-1), g) {
+		return false
+	}
+	s = (s*0x5deece66d + 0xb) & mask20
+	return hasResidue(c.z0, c.z1, int(s>>17)&(g-1), g)
+}
+
+// hasResidue 判断 [lo, hi] 内是否存在对 g 取模等于 m 的整数。
+func hasResidue(lo, hi, m, g int) bool {
+	v := lo + ((m-lo)%g+g)%g
+	return v <= hi
+}
+
+// crackFullOK 用完整的 48 位结构种子检查观察。
+func (f *Finder) crackFullOK(obs []crackObs, seed uint64) bool {
+	// 先做不分配内存的快速检查
+	for i := range obs {
+		c := &obs[i]
+		if !c.linear {
+			continue
+		}
+		px, pz := getFeatureChunkInRegion(c.config, seed, c.regX, c.regZ)
+		if px < c.x0 || px > c.x1 || pz < c.z0 || pz > c.z1 {
+			return false
+		}
+	}
+	for i := range obs {
+		c := &obs[i]
+		p, err := f.GetStructurePos(c.st, seed, c.regX, c.regZ)
+		if err != nil || p == nil {
+			return false
+		}
+		px, pz := p.X>>4-c.regX*c.config.RegionSize, p.Z>>4-c.regZ*c.config.RegionSize
+		if px < c.x0 || px > c.x1 || pz < c.z0 || pz > c.z1 {
+			return false
+		}
+	}
+	return true
+}
+
+// CrackStructureSeeds 根据观察到的结构位置恢复候选的 48 位结构种子，结果通过返回的 channel 逐个输出，
+// 搜索结束或 ctx 被取消时关闭。
+//
+// 先逆推 LCG 的低位：next(31) 的低 3 位只取决于种子的低 20 位。ChunkRange 为偶数且不是 2 的幂时
+// （例如女巫小屋和前哨站的 24、1.18+ 村庄的 26），偏移对 g = min(ChunkRange 的 2 的幂因子, 8) 的余数
+// 由低 20 位决定，每个精确的观察大约保留 1/g² 的低 20 位。之后对每个通过筛选的低 20 位暴力枚举高 28 位
+// （2^28 ≈ 2.7 亿次检查）并检查全部观察，没有使用格基规约，总开销为 通过筛选的低位数 × 2^28。
+// ChunkRange 为 2 的幂（例如远古城市的 16）或奇数的观察，以及三角分布的结构（例如 1.18 前的海底神殿
+// 和林地府邸）不参与低位筛选，它们的偏移取决于种子的高位，无法逆推。没有任何观察能缩小低 20 位时
+// 搜索需要遍历全部 2^48 个种子，此时返回错误而不是开始搜索。4 个以上精确的女巫小屋或前哨站通常只剩一个低 20 位，
+// 搜索约需单核数秒。相邻 region 的偏移高度相关，即使有十几个精确的观察，结果也可能有上百个候选种子；
+// 相距较远的观察更能缩小候选范围，剩余候选可以用 ExpandStructureSeed 的生物群系检查进一步筛选。
+func (f *Finder) CrackStructureSeeds(ctx context.Context, obs []StructureObservation, workers int) (<-chan uint64, error) {
+	cobs, err := f.prepareCrack(obs)
+	if err != nil {
+		return nil, err
+	}
+	constrained := false
+	for i := range cobs {
+		constrained = constrained || cobs[i].constrainsLow()
+	}
+	if !constrained {
+		return nil, fmt.Errorf("observations do not constrain the low 20 seed bits, the search would scan all 2^48 seeds")
+	}
+	workers = max(workers, 1)
+
+	// 每个任务是一个通过筛选的低 20 位和一段高位，通过筛选的低位很少时也能让所有 worker 并行
+	type task struct{ low, high uint64 }
+	const highBlock = 1 << 22
+	out := make(chan uint64, 64)
+	tasks := make(chan task, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for t := range tasks {
+				if ctx.Err() != nil {
+					return
+				}
+				for high := t.high; high < t.high+highBlock; high++ {
+					seed := high<<20 | t.low
+					if !f.crackFullOK(cobs, seed) {
+						continue
+					}
+					select {
+					case out <- seed:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(tasks)
+	next:
+		for low := uint64(0); low < 1<<20; low++ {
+			for i := range cobs {
+				if !cobs[i].lowOK(low) {
+					continue next
+				}
+			}
+			for high := uint64(0); high < 1<<28; high += highBlock {
+				select {
+				case tasks <- task{low, high}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// ExpandStructureSeed 把 48 位结构种子扩展为完整的 64 位世界种子：枚举高 16 位，
+// 保留所有观察到的主世界结构都能在其生物群系中生成的种子（下界和末地结构不参与检查）。
+func (f *Finder) ExpandStructureSeed(ctx context.Context, seed48 uint64, obs []StructureObservation, flags uint32) (<-chan uint64, error) {
+	cobs, err := f.prepareCrack(obs)
+	if err != nil {
+		return nil, err
+	}
+	search := SeedSearch{
+		Version: f.Version,
+		Flags:   flags,
+		Start:   seed48 & mask48,
+		End:     seed48&mask48 + 1,
+	}
+	for _, c := range cobs {
+		if c.config.Dim == DimOverworld {
+			search.Biome = append(search.Biome, StructureViable(c.st, c.regX, c.regZ))
+		}
+	}
+	if len(search.Biome) == 0 {
+		// 没有可检查的主世界结构时输出全部 2^16 个种子
+		search.Biome = append(search.Biome, func(*Generator) bool { return true })
+	}
+	return search.Run(ctx)
+}
@@ -0,0 +1,51 @@
+package gobiomes
+
+import (
+	"context"
+	"strings"
+	"testing"
+)
+
+func TestCrackStructureSeeds(t *testing.T) {
+	if testing.Short() {
+		t.Skip("enumerates 2^28 high bits")
+	}
+	f := NewFinder(MC_1_16_5)
+	const seed = 0x7b3c_19a2_e845
+	var obs []StructureObservation
+	for _, reg := range [][2]int{{0, 0}, {3, -2}, {-5, 4}, {7, 7}, {-9, -3}, {2, 11}, {-12, 9}, {10, -11}} {
+		p, err := f.GetStructurePos(SwampHut, seed, reg[0], reg[1])
+		if err != nil || p == nil {
+			t.Fatalf("no hut in region %v: %v", reg, err)
+		}
+		obs = append(obs, StructureObservation{Type: SwampHut, Pos: *p})
+	}
+	out, err := f.CrackStructureSeeds(context.Background(), obs, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var found []uint64
+	for s := range out {
+		found = append(found, s)
+	}
+	ok := false
+	for _, s := range found {
+		ok = ok || s == seed
+		for _, o := range obs {
+			if p, _ := f.GetStructurePos(SwampHut, s, o.Pos.X>>9, o.Pos.Z>>9); p == nil || *p != o.Pos {
+				t.Errorf("candidate %#x: hut at %v, observed %v", s, p, o.Pos)
+			}
+		}
+	}
+	if !ok {
+		t.Errorf("seed %#x not recovered, got %#x", uint64(seed), found)
+	}
+}
+
+func TestCrackStructureSeedsUnconstrained(t *testing.T) {
+	// 远古城市的 ChunkRange 为 16，不约束低 20 位，应当直接报错
+	ancient := []StructureObservation{{Type: AncientCity, Pos: Pos{160, -320}}}
+	if _, err := NewFinder(MC_1_20_6).CrackStructureSeeds(context.Background(), ancient, 1); err == nil || !strings.Contains(err.Error(), "2^48") {
+		t.Errorf("ancient city only: %v, want an error instead of a 2^48 scan", err)
+	}
+}
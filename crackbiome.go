@@ -0,0 +1,231 @@
+package gobiomes
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// BiomeObservation 是一次观察到的生物群系。X, Y, Z 是 Scale 比例下的坐标（与 GetBiomeAt 相同），
+// Scale 为 0 时按 1:1 处理。1.18 以前 Y 被忽略，Scale 可以是 1、4、16、64 或 256；1.18+ 只能是 1 或 4。
+type BiomeObservation struct {
+	Scale   int
+	X, Y, Z int
+	Biome   Biome
+}
+
+func (o BiomeObservation) scale() int {
+	if o.Scale == 0 {
+		return 1
+	}
+	return o.Scale
+}
+
+// biomeSelectivity 粗略估计观察到生物群系 b 能排除多少种子，越大越稀有。
+func biomeSelectivity(b Biome) int {
+	switch b {
+	case MushroomFields, MushroomFieldShore, IceSpikes, ModifiedJungle, ModifiedJungleEdge,
+		ModifiedGravellyMountains, ShatteredSavannaPlateau, SnowyTaigaMountains,
+		GiantSpruceTaigaHills, TallBirchHills, DarkForestHills, SwampHills:
+		return 4
+	case Badlands, WoodedBadlandsPlateau, BadlandsPlateau, Jungle, JungleHills, JungleEdge,
+		GiantTreeTaiga, GiantTreeTaigaHills, GiantSpruceTaiga, ShatteredSavanna, FlowerForest,
+		TallBirchForest, SunflowerPlains, DesertLakes, GravellyMountains, TaigaMountains,
+		WarmOcean, DeepLukewarmOcean, DeepFrozenOcean:
+		return 3
+	case Ocean, DeepOcean, Plains, Forest, River:
+		return 1
+	}
+	return 2
+}
+
+// biomeScaleCost 返回在版本 mc 中检查比例为 scale 的观察的相对代价，scale 不受 GetBiomeAt 支持时返回 false。
+// 1.18 以前越粗的 layer 越便宜；1.18+ 的 1:4 采样不含偏移噪声，比 1:1 便宜。
+func biomeScaleCost(mc, scale int) (int, bool) {
+	if mc >= MC_1_18 {
+		switch scale {
+		case 4:
+			return 0, true
+		case 1:
+			return 1, true
+		}
+		return 0, false
+	}
+	switch scale {
+	case 256:
+		return 0, true
+	case 64:
+		return 1, true
+	case 16:
+		return 2, true
+	case 4:
+		return 3, true
+	case 1:
+		return 4, true
+	}
+	return 0, false
+}
+
+// OrderBiomeObservations 返回按检查代价和选择性排序后的观察：版本 mc 中代价越低的比例排在前面
+// （1.18 以前的上层 layer、1.18+ 不含偏移噪声的 1:4 采样），同一比例下越稀有的生物群系越靠前。
+// 重复的观察会被去掉。比例不受该版本支持时（1.18+ 只支持 1 和 4）返回错误。
+func OrderBiomeObservations(mc int, obs []BiomeObservation) ([]BiomeObservation, error) {
+	out := make([]BiomeObservation, 0, len(obs))
+	seen := make(map[BiomeObservation]bool, len(obs))
+	cost := make(map[BiomeObservation]int, len(obs))
+	for _, o := range obs {
+		o.Scale = o.scale()
+		c, ok := biomeScaleCost(mc, o.Scale)
+		if !ok {
+			return nil, fmt.Errorf("biome scale 1:%d not supported in version %v", o.Scale, mc)
+		}
+		if !seen[o] {
+			seen[o] = true
+			cost[o] = c
+			out = append(out, o)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if ci, cj := cost[out[i]], cost[out[j]]; ci != cj {
+			return ci < cj
+		}
+		return biomeSelectivity(out[i].Biome) > biomeSelectivity(out[j].Biome)
+	})
+	return out, nil
+}
+
+// CheckBiomeObservations 判断 gen 当前的种子是否与所有观察一致，按给定顺序检查，遇到不一致时立即返回。
+// gen 需要已经以候选种子 ApplySeed 到主世界。
+func CheckBiomeObservations(gen *Generator, obs []BiomeObservation) bool {
+	for _, o := range obs {
+		if gen.GetBiomeAt(o.scale(), o.X, o.Y, o.Z) != o.Biome {
+			return false
+		}
+	}
+	return true
+}
+
+// biomeCrackBlock 是一段待检查的种子：base + i*step，i = 0 .. n-1。
+type biomeCrackBlock struct {
+	base, step, n uint64
+}
+
+// crackBiomes 用 workers 个 worker 检查 blocks 中的种子，与所有观察一致的种子写入返回的 channel。
+func crackBiomes(ctx context.Context, version int, flags uint32, obs []BiomeObservation, blocks <-chan biomeCrackBlock, workers int) (<-chan uint64, error) {
+	if len(obs) == 0 {
+		return nil, fmt.Errorf("no biome observations")
+	}
+	if version < MC_B1_8 {
+		return nil, fmt.Errorf("biome cracking not supported in version %v", version)
+	}
+	order, err := OrderBiomeObservations(version, obs)
+	if err != nil {
+		return nil, err
+	}
+	workers = max(workers, 1)
+
+	out := make(chan uint64, 64)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			gen := NewGenerator(version, flags)
+			for {
+				var b biomeCrackBlock
+				var ok bool
+				select {
+				case b, ok = <-blocks:
+				case <-ctx.Done():
+					return
+				}
+				if !ok {
+					return
+				}
+				for i := uint64(0); i < b.n; i++ {
+					if i&0xff == 0 && ctx.Err() != nil {
+						return
+					}
+					seed := b.base + i*b.step
+					gen.ApplySeed(seed, DimOverworld)
+					if !CheckBiomeObservations(gen, order) {
+						continue
+					}
+					select {
+					case out <- seed:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+// CrackBiomeSeeds 根据观察到的生物群系筛选世界种子。seeds48 提供候选的 48 位结构种子
+// （例如 CrackStructureSeeds 的输出），对每个结构种子枚举高 16 位，与所有观察一致的 64 位种子
+// 通过返回的 channel 输出；seeds48 关闭且检查完成或 ctx 被取消时关闭。
+// 观察会先经 OrderBiomeObservations 排序，使便宜且排除能力强的检查先执行。
+func CrackBiomeSeeds(ctx context.Context, version int, flags uint32, obs []BiomeObservation, seeds48 <-chan uint64, workers int) (<-chan uint64, error) {
+	blocks := make(chan biomeCrackBlock)
+	out, err := crackBiomes(ctx, version, flags, obs, blocks, workers)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(blocks)
+		for {
+			var lower uint64
+			var ok bool
+			select {
+			case lower, ok = <-seeds48:
+			case <-ctx.Done():
+				return
+			}
+			if !ok {
+				return
+			}
+			select {
+			case blocks <- biomeCrackBlock{lower & mask48, 1 << 48, 1 << 16}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out, nil
+}
+
+// CrackBiomeSeedRange 在不知道结构种子时根据观察到的生物群系筛选世界种子：依次检查 start, start+1, ..., end-1
+// （按 2^64 取模，因此 start > end 的区间会回绕，例如 uint64(int64(math.MinInt32)) 到 1<<31 是所有 32 位种子）。
+// 其余参数和输出同 CrackBiomeSeeds。
+func CrackBiomeSeedRange(ctx context.Context, version int, flags uint32, obs []BiomeObservation, start, end uint64, workers int) (<-chan uint64, error) {
+	if start == end {
+		return nil, fmt.Errorf("empty seed range [%d, %d)", start, end)
+	}
+	const blockSize = 1 << 12
+	blocks := make(chan biomeCrackBlock)
+	out, err := crackBiomes(ctx, version, flags, obs, blocks, workers)
+	if err != nil {
+		return nil, err
+	}
+	go func() {
+		defer close(blocks)
+		for base, left := start, end-start; left > 0; {
+			n := min(left, blockSize)
+			select {
+			case blocks <- biomeCrackBlock{base, 1, n}:
+			case <-ctx.Done():
+				return
+			}
+			base += n
+			left -= n
+		}
+	}()
+	return out, nil
+}
@@ -0,0 +1,190 @@
+package gobiomes
+
+// 地牢刷怪笼的生物类型，顺序与 MonsterRoomFeature.MOBS 一致。
+var dungeonMobs = [4]string{"skeleton", "zombie", "zombie", "spider"}
+
+// 地牢有效性：是否能生成取决于周围地形（地板和天花板完整、墙上有 1~5 个开口）。
+const (
+	DungeonUnchecked = iota // 没有地形模型，未检查
+	DungeonValid
+	DungeonInvalid
+)
+
+// Dungeon 描述 chunk 内的一次地牢（怪物房间）生成尝试。
+type Dungeon struct {
+	Pos      Pos3   // 刷怪笼位置（房间中心）
+	RadiusX  int    // 房间内部 X 方向半宽（2 或 3）
+	RadiusZ  int    // 房间内部 Z 方向半宽（2 或 3）
+	Mob      string // 刷怪笼生物：zombie, skeleton, spider
+	Validity int    // DungeonUnchecked 等
+}
+
+// dungeonMob 模拟 MonsterRoomFeature.place 在房间有效后的随机数消耗，返回刷怪笼生物。
+// 假设地板和墙体完整（地板下方为实心方块、箱子候选位置不贴近墙上的开口）。
+func dungeonMob(r *WorldgenRandom, rx, rz int) string {
+	// 地板方块：每块以 1/4 概率替换为圆石
+	floor := (2*rx + 3) * (2*rz + 3)
+	for i := 0; i < floor; i++ {
+		r.NextInt(4)
+	}
+	// 两个箱子，各尝试 3 次，要求候选位置恰好与一个实心方块相邻
+	var chests [][2]int
+	solid := func(x, z int) bool {
+		if x < -rx || x > rx || z < -rz || z > rz {
+			return true
+		}
+		for _, c := range chests {
+			if c[0] == x && c[1] == z {
+				return true
+			}
+		}
+		return false
+	}
+	for c := 0; c < 2; c++ {
+		for t := 0; t < 3; t++ {
+			x := r.NextInt(rx*2+1) - rx
+			z := r.NextInt(rz*2+1) - rz
+			if solid(x, z) {
+				continue
+			}
+			n := 0
+			for _, d := range [4][2]int{{0, -1}, {1, 0}, {0, 1}, {-1, 0}} {
+				if solid(x+d[0], z+d[1]) {
+					n++
+				}
+			}
+			if n != 1 {
+				continue
+			}
+			chests = append(chests, [2]int{x, z})
+			r.NextLong() // 战利品表种子
+			break
+		}
+	}
+	return dungeonMobs[r.NextInt(4)]
+}
+
+// skipLegacyLake 模拟 WorldGenLakes.generate 的随机数消耗（假设湖泊成功生成）。
+func skipLegacyLake(r *WorldgenRandom, y int, lava bool) {
+	if y <= 4 {
+		return
+	}
+	var shape [2048]bool
+	n := r.NextInt(4) + 4
+	for i := 0; i < n; i++ {
+		d0 := r.NextDouble()*6 + 3
+		d1 := r.NextDouble()*4 + 2
+		d2 := r.NextDouble()*6 + 3
+		d3 := r.NextDouble()*(16-d0-2) + 1 + d0/2
+		d4 := r.NextDouble()*(8-d1-4) + 2 + d1/2
+		d5 := r.NextDouble()*(16-d2-2) + 1 + d2/2
+		for x := 1; x < 15; x++ {
+			for z := 1; z < 15; z++ {
+				for h := 1; h < 7; h++ {
+					dx := (float64(x) - d3) / (d0 / 2)
+					dy := (float64(h) - d4) / (d1 / 2)
+					dz := (float64(z) - d5) / (d2 / 2)
+					if dx*dx+dy*dy+dz*dz < 1 {
+						shape[(x*16+z)*8+h] = true
+					}
+				}
+			}
+		}
+	}
+	if !lava {
+		return
+	}
+	// 熔岩湖外壳：上半部分以 1/2 概率替换为石头
+	at := func(x, z, h int) bool { return shape[(x*16+z)*8+h] }
+	for x := 0; x < 16; x++ {
+		for z := 0; z < 16; z++ {
+			for h := 0; h < 8; h++ {
+				edge := !at(x, z, h) && (x < 15 && at(x+1, z, h) || x > 0 && at(x-1, z, h) ||
+					z < 15 && at(x, z+1, h) || z > 0 && at(x, z-1, h) ||
+					h < 7 && at(x, z, h+1) || h > 0 && at(x, z, h-1))
+				if edge && h >= 4 {
+					r.NextInt(2)
+				}
+			}
+		}
+	}
+}
+
+// GetDungeons 返回 chunk (chunkX, chunkZ) 内所有地牢生成尝试。
+// 在没有地形模型时，每个尝试的有效性为 DungeonUnchecked；刷怪笼生物按“此前的尝试均未生成、房间完整”计算。
+// biome 为 chunk 的生物群系，仅 1.13 之前用于判断沙漠中不生成的水湖。
+// 1.13 之前地牢与湖泊共用 chunk 的装饰随机数，这里假设触发的湖泊均成功生成，且该 chunk 不与结构相交。
+func (f *Finder) GetDungeons(seed uint64, chunkX, chunkZ int, biome Biome) []Dungeon {
+	mc := f.Version
+	var out []Dungeon
+	// 对每个尝试，用随机数的副本模拟其后续消耗以确定生物类型
+	add := func(r *WorldgenRandom, x, y, z int) {
+		d := Dungeon{Pos: Pos3{x, y, z}}
+		d.RadiusX = r.NextInt(2) + 2
+		d.RadiusZ = r.NextInt(2) + 2
+		c := *r
+		d.Mob = dungeonMob(&c, d.RadiusX, d.RadiusZ)
+		out = append(out, d)
+	}
+
+	x0, z0 := chunkX*16, chunkZ*16
+	switch {
+	case mc <= MC_1_12:
+		r := f.FeatureRandom(seed, chunkX, chunkZ, 0, StepUndergroundStructures)
+		if biome != Desert && biome != DesertHills && r.NextInt(4) == 0 {
+			r.NextInt(16)
+			y := r.NextInt(256)
+			r.NextInt(16)
+			skipLegacyLake(r, y, false)
+		}
+		if r.NextInt(8) == 0 {
+			r.NextInt(16)
+			y := r.NextInt(r.NextInt(248) + 8)
+			r.NextInt(16)
+			if y < 63 || r.NextInt(10) == 0 {
+				skipLegacyLake(r, y, true)
+			}
+		}
+		for i := 0; i < 8; i++ {
+			x := x0 + r.NextInt(16) + 8
+			y := r.NextInt(256)
+			z := z0 + r.NextInt(16) + 8
+			add(r, x, y, z)
+		}
+
+	case mc <= MC_1_17:
+		// 1.13~1.15 该 step 前有矿井、要塞、埋藏的宝藏三个结构特征；1.16+ 为矿井和埋藏的宝藏
+		index := 2
+		if mc <= MC_1_15 {
+			index = 3
+		}
+		r := f.FeatureRandom(seed, chunkX, chunkZ, index, StepUndergroundStructures)
+		for i := 0; i < 8; i++ {
+			var x, y, z int
+			if mc <= MC_1_15 {
+				x = x0 + r.NextInt(16)
+				y = r.NextInt(256)
+				z = z0 + r.NextInt(16)
+			} else {
+				x = x0 + r.NextInt(16)
+				z = z0 + r.NextInt(16)
+				y = r.NextInt(256)
+			}
+			add(r, x, y, z)
+		}
+
+	default:
+		// monster_room (y: 0~319) 与 monster_room_deep (y: -58~-1)，排在沙漠化石之后
+		features := []struct{ index, count, ymin, yrange int }{{2, 10, 0, 320}, {3, 4, -58, 58}}
+		for _, ft := range features {
+			r := f.FeatureRandom(seed, chunkX, chunkZ, ft.index, StepUndergroundStructures)
+			for i := 0; i < ft.count; i++ {
+				x := x0 + r.NextInt(16)
+				z := z0 + r.NextInt(16)
+				y := ft.ymin + r.NextInt(ft.yrange)
+				add(r, x, y, z)
+			}
+		}
+	}
+	return out
+}
@@ -0,0 +1,296 @@
+package gobiomes
+
+// 末地城部件类型，对应 EndCityPieces 中使用的模板。
+const (
+	EndCityBaseFloor = iota
+	EndCityBaseRoof
+	EndCityBridgeEnd
+	EndCityBridgeGentleStairs
+	EndCityBridgePiece
+	EndCityBridgeSteepStairs
+	EndCityFatTowerBase
+	EndCityFatTowerMiddle
+	EndCityFatTowerTop
+	EndCitySecondFloor1
+	EndCitySecondFloor2
+	EndCitySecondRoof
+	EndCityShip
+	EndCityThirdFloor1
+	EndCityThirdFloor2
+	EndCityThirdRoof
+	EndCityTowerBase
+	EndCityTowerFloor
+	EndCityTowerPiece
+	EndCityTowerTop
+)
+
+// endCityTemplates 记录末地城各模板的名称和尺寸 (sx, sy, sz)。
+var endCityTemplates = [...]struct {
+	name       string
+	sx, sy, sz int
+}{
+	EndCityBaseFloor:          {"base_floor", 10, 4, 10},
+	EndCityBaseRoof:           {"base_roof", 12, 2, 12},
+	EndCityBridgeEnd:          {"bridge_end", 5, 6, 2},
+	EndCityBridgeGentleStairs: {"bridge_gentle_stairs", 5, 7, 8},
+	EndCityBridgePiece:        {"bridge_piece", 5, 6, 4},
+	EndCityBridgeSteepStairs:  {"bridge_steep_stairs", 5, 7, 4},
+	EndCityFatTowerBase:       {"fat_tower_base", 13, 4, 13},
+	EndCityFatTowerMiddle:     {"fat_tower_middle", 13, 8, 13},
+	EndCityFatTowerTop:        {"fat_tower_top", 17, 6, 17},
+	EndCitySecondFloor1:       {"second_floor_1", 12, 8, 12},
+	EndCitySecondFloor2:       {"second_floor_2", 12, 8, 12},
+	EndCitySecondRoof:         {"second_roof", 14, 2, 14},
+	EndCityShip:               {"ship", 13, 24, 29},
+	EndCityThirdFloor1:        {"third_floor_1", 14, 8, 14},
+	EndCityThirdFloor2:        {"third_floor_2", 14, 8, 14},
+	EndCityThirdRoof:          {"third_roof", 16, 2, 16},
+	EndCityTowerBase:          {"tower_base", 7, 7, 7},
+	EndCityTowerFloor:         {"tower_floor", 7, 4, 7},
+	EndCityTowerPiece:         {"tower_piece", 7, 4, 7},
+	EndCityTowerTop:           {"tower_top", 9, 5, 9},
+}
+
+// rotatePos 对应 StructureTemplate.transform（以原点为轴心，不镜像）。
+func rotatePos(x, y, z, rot int) Pos3 {
+	switch rot & 3 {
+	case 1:
+		return Pos3{-z, y, x}
+	case 2:
+		return Pos3{-x, y, -z}
+	case 3:
+		return Pos3{z, y, -x}
+	}
+	return Pos3{x, y, z}
+}
+
+// templateBoundingBox 返回尺寸为 (sx, sy, sz) 的模板以 rot 旋转后放置在 p 处的包围盒。
+func templateBoundingBox(p Pos3, rot, sx, sy, sz int) BoundingBox {
+	a := rotatePos(0, 0, 0, rot)
+	b := rotatePos(sx-1, sy-1, sz-1, rot)
+	bb := BoundingBox{
+		MinX: min(a.X, b.X), MinY: min(a.Y, b.Y), MinZ: min(a.Z, b.Z),
+		MaxX: max(a.X, b.X), MaxY: max(a.Y, b.Y), MaxZ: max(a.Z, b.Z),
+	}
+	bb.MinX += p.X
+	bb.MaxX += p.X
+	bb.MinY += p.Y
+	bb.MaxY += p.Y
+	bb.MinZ += p.Z
+	bb.MaxZ += p.Z
+	return bb
+}
+
+// endCityGen 保存末地城递归生成过程中的状态。
+type endCityGen struct {
+	r           *Rng
+	shipCreated bool
+}
+
+// endCityGenerator 对应 EndCityPieces.SectionGenerator。
+type endCityGenerator func(g *endCityGen, depth int, parent *Piece, off Pos3, list *[]Piece) bool
+
+// addEndCityPiece 对应 EndCityPieces.addPiece + addHelper：以 parent 为基准偏移 off 放置模板并加入 list。
+func addEndCityPiece(list *[]Piece, parent *Piece, off Pos3, typ, rot int) *Piece {
+	d := rotatePos(off.X, off.Y, off.Z, parent.Rotation)
+	p := Pos3{parent.Pos.X + d.X, parent.Pos.Y + d.Y, parent.Pos.Z + d.Z}
+	return appendEndCityPiece(list, p, typ, rot)
+}
+
+func appendEndCityPiece(list *[]Piece, p Pos3, typ, rot int) *Piece {
+	t := endCityTemplates[typ]
+	*list = append(*list, Piece{
+		Name:     t.name,
+		Type:     typ,
+		Pos:      p,
+		BB:       templateBoundingBox(p, rot, t.sx, t.sy, t.sz),
+		Rotation: rot & 3,
+	})
+	return &(*list)[len(*list)-1]
+}
+
+// recursiveChildren 对应 EndCityPieces.recursiveChildren。
+func (g *endCityGen) recursiveChildren(gen endCityGenerator, depth int, parent *Piece, off Pos3, list *[]Piece) bool {
+	if depth > 8 {
+		return false
+	}
+	var sub []Piece
+	if !gen(g, depth, parent, off, &sub) {
+		return false
+	}
+	tag := int(g.r.Next(32))
+	collides := false
+	for i := range sub {
+		sub[i].Depth = tag
+		for j := range *list {
+			if (*list)[j].BB.Intersects(sub[i].BB) {
+				if (*list)[j].Depth != parent.Depth {
+					collides = true
+				}
+				break
+			}
+		}
+		if collides {
+			break
+		}
+	}
+	if collides {
+		return false
+	}
+	*list = append(*list, sub...)
+	return true
+}
+
+var (
+	endCityTowerBridges = [4]struct {
+		rot int
+		off Pos3
+	}{{0, Pos3{1, -1, 0}}, {1, Pos3{6, -1, 1}}, {3, Pos3{0, -1, 5}}, {2, Pos3{5, -1, 6}}}
+	endCityFatTowerBridges = [4]struct {
+		rot int
+		off Pos3
+	}{{0, Pos3{4, -1, 0}}, {1, Pos3{12, -1, 4}}, {3, Pos3{0, -1, 8}}, {2, Pos3{8, -1, 12}}}
+)
+
+// 由于 list 在 append 时可能扩容，部件之间以副本传递，避免悬垂指针。
+
+func endCityTower(g *endCityGen, depth int, parent *Piece, _ Pos3, list *[]Piece) bool {
+	rot := parent.Rotation
+	r := g.r
+	ox := 3 + r.NextInt(2)
+	oz := 3 + r.NextInt(2)
+	p := *addEndCityPiece(list, parent, Pos3{ox, -3, oz}, EndCityTowerBase, rot)
+	p = *addEndCityPiece(list, &p, Pos3{0, 7, 0}, EndCityTowerPiece, rot)
+	var bridgeBase *Piece
+	if r.NextInt(3) == 0 {
+		b := p
+		bridgeBase = &b
+	}
+	n := 1 + r.NextInt(3)
+	for i := 0; i < n; i++ {
+		p = *addEndCityPiece(list, &p, Pos3{0, 4, 0}, EndCityTowerPiece, rot)
+		if i < n-1 && r.Next(1) != 0 {
+			b := p
+			bridgeBase = &b
+		}
+	}
+	if bridgeBase != nil {
+		for _, tb := range endCityTowerBridges {
+			if r.Next(1) == 0 {
+				continue
+			}
+			be := *addEndCityPiece(list, bridgeBase, tb.off, EndCityBridgeEnd, rot+tb.rot)
+			g.recursiveChildren(endCityTowerBridge, depth+1, &be, Pos3{}, list)
+		}
+		addEndCityPiece(list, &p, Pos3{-1, 4, -1}, EndCityTowerTop, rot)
+	} else if depth == 7 {
+		addEndCityPiece(list, &p, Pos3{-1, 4, -1}, EndCityTowerTop, rot)
+	} else {
+		return g.recursiveChildren(endCityFatTower, depth+1, &p, Pos3{}, list)
+	}
+	return true
+}
+
+func endCityTowerBridge(g *endCityGen, depth int, parent *Piece, _ Pos3, list *[]Piece) bool {
+	rot := parent.Rotation
+	r := g.r
+	n := r.NextInt(4) + 1
+	p := addEndCityPiece(list, parent, Pos3{0, 0, -4}, EndCityBridgePiece, rot)
+	p.Depth = -1
+	q := *p
+	y := 0
+	for i := 0; i < n; i++ {
+		if r.Next(1) != 0 {
+			q = *addEndCityPiece(list, &q, Pos3{0, y, -4}, EndCityBridgePiece, rot)
+			y = 0
+			continue
+		}
+		if r.Next(1) != 0 {
+			q = *addEndCityPiece(list, &q, Pos3{0, y, -4}, EndCityBridgeSteepStairs, rot)
+		} else {
+			q = *addEndCityPiece(list, &q, Pos3{0, y, -8}, EndCityBridgeGentleStairs, rot)
+		}
+		y = 4
+	}
+	if g.shipCreated || r.NextInt(10-depth) != 0 {
+		if !g.recursiveChildren(endCityHouseTower, depth+1, &q, Pos3{-3, y + 1, -11}, list) {
+			return false
+		}
+	} else {
+		ox := -8 + r.NextInt(8)
+		oz := -70 + r.NextInt(10)
+		addEndCityPiece(list, &q, Pos3{ox, y, oz}, EndCityShip, rot)
+		g.shipCreated = true
+	}
+	p = addEndCityPiece(list, &q, Pos3{4, y, 0}, EndCityBridgeEnd, rot+2)
+	p.Depth = -1
+	return true
+}
+
+func endCityHouseTower(g *endCityGen, depth int, parent *Piece, off Pos3, list *[]Piece) bool {
+	if depth > 8 {
+		return false
+	}
+	rot := parent.Rotation
+	p := *addEndCityPiece(list, parent, off, EndCityBaseFloor, rot)
+	switch g.r.NextInt(3) {
+	case 0:
+		addEndCityPiece(list, &p, Pos3{-1, 4, -1}, EndCityBaseRoof, rot)
+	case 1:
+		p = *addEndCityPiece(list, &p, Pos3{-1, 0, -1}, EndCitySecondFloor2, rot)
+		p = *addEndCityPiece(list, &p, Pos3{-1, 8, -1}, EndCitySecondRoof, rot)
+		g.recursiveChildren(endCityTower, depth+1, &p, Pos3{}, list)
+	case 2:
+		p = *addEndCityPiece(list, &p, Pos3{-1, 0, -1}, EndCitySecondFloor2, rot)
+		p = *addEndCityPiece(list, &p, Pos3{-1, 4, -1}, EndCityThirdFloor2, rot)
+		p = *addEndCityPiece(list, &p, Pos3{-1, 8, -1}, EndCityThirdRoof, rot)
+		g.recursiveChildren(endCityTower, depth+1, &p, Pos3{}, list)
+	}
+	return true
+}
+
+func endCityFatTower(g *endCityGen, depth int, parent *Piece, _ Pos3, list *[]Piece) bool {
+	rot := parent.Rotation
+	r := g.r
+	p := *addEndCityPiece(list, parent, Pos3{-3, 4, -3}, EndCityFatTowerBase, rot)
+	p = *addEndCityPiece(list, &p, Pos3{0, 4, 0}, EndCityFatTowerMiddle, rot)
+	for i := 0; i < 2 && r.NextInt(3) != 0; i++ {
+		p = *addEndCityPiece(list, &p, Pos3{0, 8, 0}, EndCityFatTowerMiddle, rot)
+		for _, tb := range endCityFatTowerBridges {
+			if r.Next(1) == 0 {
+				continue
+			}
+			be := *addEndCityPiece(list, &p, tb.off, EndCityBridgeEnd, rot+tb.rot)
+			g.recursiveChildren(endCityTowerBridge, depth+1, &be, Pos3{}, list)
+		}
+	}
+	addEndCityPiece(list, &p, Pos3{-2, 8, -2}, EndCityFatTowerTop, rot)
+	return true
+}
+
+// GetEndCityPieces 生成末地城在 chunk (chunkX, chunkZ) 处的全部部件，对应 EndCityPieces.startHouseTower。
+// y 为末地城起点高度，由末地地形决定（原版要求不低于 60）；部件之间的相对位置与 y 无关。
+func (f *Finder) GetEndCityPieces(seed uint64, chunkX, chunkZ, y int) []Piece {
+	g := &endCityGen{r: &Rng{seed: f.ChunkGenerateRnd(seed, chunkX, chunkZ)}}
+	rot := g.r.NextInt(4)
+
+	list := make([]Piece, 0, 64)
+	p := *appendEndCityPiece(&list, Pos3{chunkX*16 + 8, y, chunkZ*16 + 8}, EndCityBaseFloor, rot)
+	p = *addEndCityPiece(&list, &p, Pos3{-1, 0, -1}, EndCitySecondFloor1, rot)
+	p = *addEndCityPiece(&list, &p, Pos3{-1, 4, -1}, EndCityThirdFloor1, rot)
+	p = *addEndCityPiece(&list, &p, Pos3{-1, 8, -1}, EndCityThirdRoof, rot)
+	g.recursiveChildren(endCityTower, 1, &p, Pos3{}, &list)
+	return list
+}
+
+// GetEndShip 返回末地城中末影船部件；末地城没有末影船时返回 nil。
+// pos 为 GetStructurePos(EndCity, ...) 返回的位置，y 的含义同 GetEndCityPieces。
+func (f *Finder) GetEndShip(seed uint64, pos Pos, y int) *Piece {
+	for _, p := range f.GetEndCityPieces(seed, pos.X>>4, pos.Z>>4, y) {
+		if p.Type == EndCityShip {
+			ship := p
+			return &ship
+		}
+	}
+	return nil
+}
@@ -25,11 +25,7 @@ import (
 //   1) 以 P 为中心、半径 outerR=128 的球体可以包含两个神殿的全部“刷怪体积”
 //   2) 以 P 为中心、半径 innerR=24 的球体内不包含两个神殿的任何刷怪体积
 //
-// 这里对刷怪体积做了简化建模：
-// - X/Z：58×58（以结构生成尝试点为中心，半径 29）
-// - Y：39..61（绝对高度，默认守卫者刷怪高度区间）
-//
-// 注：如果你需要与游戏内“精确边界/偏移”100%一致，需要进一步引入结构的 bounding box/variant。
+// 刷怪体积取神殿的实际包围盒（GetBoundingBox）：58×58，Y 39..61。
 
 type pos2 struct {
 	X int `json:"x"`
@@ -57,7 +53,6 @@ type outJSON struct {
 	MaxDist     float64 `json:"maxDist"`
 	OuterR      float64 `json:"outerR"`
 	InnerR      float64 `json:"innerR"`
-	BoxHalfXZ   float64 `json:"boxHalfXZ"`
 	YMin        float64 `json:"yMin"`
 	YMax        float64 `json:"yMax"`
 	Monuments   int     `json:"monuments"`
@@ -134,13 +129,19 @@ func main() {
 	// 1) 扫描区域内所有可行的海底神殿位置（并行）
 	type regionTask struct{ rx, rz int }
 	tasks := make(chan regionTask, 4096)
-	found := make(chan pos2, 4096)
+	type monument struct {
+		p   pos2
+		box aabb3
+	}
+	found := make(chan monument, 4096)
 
 	positions := make([]pos2, 0, 1024)
+	boxes := make([]aabb3, 0, 1024)
 	collectDone := make(chan struct{})
 	go func() {
-		for p := range found {
-			positions = append(positions, p)
+		for m := range found {
+			positions = append(positions, m.p)
+			boxes = append(boxes, m.box)
 		}
 		close(collectDone)
 	}()
@@ -185,7 +186,11 @@ func main() {
 				if p != nil {
 					if p.X >= *minX && p.X <= *maxX && p.Z >= *minZ && p.Z <= *maxZ {
 						if wGen.IsViableStructurePos(gobiomes.Monument, p.X, p.Z, 0) {
-							found <- pos2{X: p.X, Z: p.Z}
+							bb, _, err := wGen.GetBoundingBox(gobiomes.Monument, *p)
+							if err != nil {
+								panic(err)
+							}
+							found <- monument{pos2{X: p.X, Z: p.Z}, blockBox(*bb)}
 						}
 					}
 				}
@@ -253,9 +258,7 @@ func main() {
 
 	outerR := 128.0
 	innerR := 24.0
-	boxHalfXZ := 29.0
-	yMin := 39.0
-	yMax := 61.0
+	yMin, yMax := boxes[0].minY, boxes[0].maxY
 
 	var wgPairs sync.WaitGroup
 	for w := 0; w < *workers; w++ {
@@ -280,7 +283,7 @@ func main() {
 								d := dist2D(a, b)
 								if d <= *maxD {
 									// 3D 可行性判定（找点 P）
-									ok, c := existsCenter3D(a, b, outerR, innerR, boxHalfXZ, yMin, yMax, *step)
+									ok, c := existsCenter3D(boxes[i], boxes[j], outerR, innerR, *step)
 									if ok {
 										atomic.AddInt64(&pairsTotal, 1)
 										wgt := (*maxD - d)
@@ -341,7 +344,6 @@ func main() {
 		MaxDist:     *maxD,
 		OuterR:      outerR,
 		InnerR:      innerR,
-		BoxHalfXZ:   boxHalfXZ,
 		YMin:        yMin,
 		YMax:        yMax,
 		Monuments:   len(positions),
@@ -372,16 +374,15 @@ type aabb3 struct {
 
 type p3 struct{ x, y, z float64 }
 
-func monumentBox(center pos2, halfXZ, yMin, yMax float64) aabb3 {
-	cx := float64(center.X)
-	cz := float64(center.Z)
+// blockBox 把包含端点的方块包围盒转换为连续坐标下的体积。
+func blockBox(bb gobiomes.BoundingBox) aabb3 {
 	return aabb3{
-		minX: cx - halfXZ,
-		maxX: cx + halfXZ,
-		minZ: cz - halfXZ,
-		maxZ: cz + halfXZ,
-		minY: yMin,
-		maxY: yMax,
+		minX: float64(bb.MinX),
+		maxX: float64(bb.MaxX + 1),
+		minY: float64(bb.MinY),
+		maxY: float64(bb.MaxY + 1),
+		minZ: float64(bb.MinZ),
+		maxZ: float64(bb.MaxZ + 1),
 	}
 }
 
@@ -395,9 +396,7 @@ func cornersOfBox(b aabb3) [8]p3 {
 	}
 }
 
-func existsCenter3D(a, b pos2, outerR, innerR, halfXZ, yMin, yMax, step float64) (bool, pos3) {
-	boxA := monumentBox(a, halfXZ, yMin, yMax)
-	boxB := monumentBox(b, halfXZ, yMin, yMax)
+func existsCenter3D(boxA, boxB aabb3, outerR, innerR, step float64) (bool, pos3) {
 	ca := cornersOfBox(boxA)
 	cb := cornersOfBox(boxB)
 
@@ -534,7 +533,6 @@ func renderMD(o outJSON) string {
 	s += fmt.Sprintf("- maxDist(xz): `%.0f`\n", o.MaxDist)
 	s += fmt.Sprintf("- outerR: `%.0f`\n", o.OuterR)
 	s += fmt.Sprintf("- innerR: `%.0f`\n", o.InnerR)
-	s += fmt.Sprintf("- boxHalfXZ: `%.0f` (58×58)\n", o.BoxHalfXZ)
 	s += fmt.Sprintf("- yRange: `[%.0f,%.0f]`\n", o.YMin, o.YMax)
 	s += fmt.Sprintf("- monuments: `%d`\n", o.Monuments)
 	s += fmt.Sprintf("- pairs(found): `%d`\n", o.PairsTotal)
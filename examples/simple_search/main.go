@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"gobiomes"
 )
@@ -16,20 +17,17 @@ func main() {
 	// 搜索村庄
 	fmt.Printf("正在搜索种子 %d (版本 %d) 中的村庄...\n", seed, mc)
 
-	for rz := -10; rz <= 10; rz++ {
-		for rx := -10; rx <= 10; rx++ {
-			pos, err := finder.GetStructurePos(gobiomes.Village, seed, rx, rz)
-			if err != nil {
-				continue
-			}
-			if pos != nil {
-				// 验证生物群系
-				if gen.IsViableStructurePos(gobiomes.Village, pos.X, pos.Z, 0) {
-					biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
-					fmt.Printf("找到村庄: x=%d, z=%d, 生物群系=%d\n", pos.X, pos.Z, biome)
-				}
-			}
+	area := gobiomes.CircleArea{Center: gobiomes.Pos{}, Radius: 5000}
+	err := finder.ForEachStructure(context.Background(), gobiomes.Village, seed, area, func(pos gobiomes.Pos) bool {
+		// 验证生物群系
+		if gen.IsViableStructurePos(gobiomes.Village, pos.X, pos.Z, 0) {
+			biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
+			fmt.Printf("找到村庄: x=%d, z=%d, 生物群系=%d\n", pos.X, pos.Z, biome)
 		}
+		return true
+	})
+	if err != nil {
+		fmt.Println(err)
 	}
 
 	// 1.17 版本的生物群系采样示例
@@ -18,7 +18,8 @@ import (
 
 // trial_chambers_pairs
 // - 搜索 40w*40w 区域内（默认 [-200000,200000] x [-200000,200000]）的试炼密室(TrialChambers)
-// - 找“二联”：两处结构生成尝试点的距离 <= maxd（默认 150）
+// - 找“二联”：两处结构生成尝试点的 3D 距离 <= maxd（默认 150）；
+//   试炼密室的起点高度在 [-40,-20] 内由种子决定，只看 X/Z 会高估二联的接近程度
 // - 权重机制：weight = maxd - dist（越近越靠上）
 // - 输出：JSON 或 Markdown（避免 HTML 打开卡顿）
 //
@@ -28,6 +29,7 @@ import (
 
 type pos struct {
 	X int `json:"x"`
+	Y int `json:"y"`
 	Z int `json:"z"`
 }
 
@@ -179,7 +181,11 @@ func main() {
 				if p != nil {
 					if p.X >= *minX && p.X <= *maxX && p.Z >= *minZ && p.Z <= *maxZ {
 						if wGen.IsViableStructurePos(gobiomes.TrialChambers, p.X, p.Z, 0) {
-							found <- pos{X: p.X, Z: p.Z}
+							y, _, err := wFinder.GetStructureY(gobiomes.TrialChambers, *seed, *p, gobiomes.None)
+							if err != nil {
+								panic(err)
+							}
+							found <- pos{X: p.X, Y: y, Z: p.Z}
 						}
 					}
 				}
@@ -374,19 +380,20 @@ func renderMD(o outJSON) string {
 	s += fmt.Sprintf("- generatedAt: `%s`\n\n", o.GeneratedAt)
 
 	s += "## Top Pairs\n\n"
-	s += "| # | A(x,z) | B(x,z) | dist | weight |\n"
+	s += "| # | A(x,y,z) | B(x,y,z) | dist | weight |\n"
 	s += "|---:|---|---|---:|---:|\n"
 	for i, p := range o.TopPairs {
-		s += fmt.Sprintf("| %d | %d,%d | %d,%d | %.2f | %.2f |\n",
-			i+1, p.A.X, p.A.Z, p.B.X, p.B.Z, p.Dist, p.Weight)
+		s += fmt.Sprintf("| %d | %d,%d,%d | %d,%d,%d | %.2f | %.2f |\n",
+			i+1, p.A.X, p.A.Y, p.A.Z, p.B.X, p.B.Y, p.B.Z, p.Dist, p.Weight)
 	}
 	return s
 }
 
 func dist(a, b pos) float64 {
 	dx := float64(a.X - b.X)
+	dy := float64(a.Y - b.Y)
 	dz := float64(a.Z - b.Z)
-	return math.Hypot(dx, dz)
+	return math.Sqrt(dx*dx + dy*dy + dz*dz)
 }
 
 func floorDiv(a, b int) int {
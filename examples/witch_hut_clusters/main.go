@@ -20,7 +20,7 @@ import (
 //     1) 以 P 为圆心、半径 128 的圆可以包含 4 个小屋“刷怪范围”
 //     2) 以 P 为圆心、半径 24 的圆不与 4 个小屋“刷怪范围”相交
 //
-// 注意：这里将“刷怪范围”近似为以女巫小屋中心点为圆心、半径 spawnR 的圆。
+// 注意：这里将“刷怪范围”近似为以女巫小屋包围盒中心为圆心、半径 spawnR 的圆。
 // spawnR 默认取 8（略保守）。如果你希望更严格/更宽松，可用 -spawnR 调整。
 
 type hut struct {
@@ -94,7 +94,13 @@ func main() {
 			if p != nil {
 				if p.X >= *minX && p.X <= *maxX && p.Z >= *minZ && p.Z <= *maxZ {
 					if gen.IsViableStructurePos(gobiomes.SwampHut, p.X, p.Z, 0) {
-						huts = append(huts, hut{X: p.X, Z: p.Z})
+						// 以小屋实际包围盒的中心作为刷怪范围中心
+						bb, _, err := gen.GetBoundingBox(gobiomes.SwampHut, *p)
+						if err != nil {
+							panic(err)
+						}
+						c := bb.Center()
+						huts = append(huts, hut{X: c.X, Z: c.Z})
 					}
 				}
 			}
@@ -0,0 +1,167 @@
+package gobiomes
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+// EyeThrow 是一次末影之眼投掷的观测：玩家位置（block 坐标）和末影之眼飞行方向的 yaw（度，
+// 与 F3 中的朝向相同：0 为 +Z，90 为 -X）。Error 是角度测量误差的标准差（度），为 0 时取 0.1。
+type EyeThrow struct {
+	X, Z  float64
+	Yaw   float64
+	Error float64
+}
+
+// StrongholdEstimate 是一个候选要塞位置及其概率。
+type StrongholdEstimate struct {
+	Pos         Pos
+	Probability float64
+}
+
+// eyeTarget 返回要塞起始 chunk 中末影之眼指向的 block 坐标。
+func eyeTarget(chunkX, chunkZ int) (float64, float64) {
+	return float64(chunkX<<4 + 4), float64(chunkZ<<4 + 4)
+}
+
+// logLikelihood 返回末影之眼指向 (x, z) 的对数似然（忽略常数项）。
+func (t EyeThrow) logLikelihood(x, z float64) float64 {
+	sigma := t.Error
+	if sigma <= 0 {
+		sigma = 0.1
+	}
+	sigma *= math.Pi / 180
+	// 指向 (dx, dz) 的 yaw 为 atan2(-dx, dz)
+	a := math.Atan2(-(x - t.X), z-t.Z)
+	d := math.Remainder(a-t.Yaw*math.Pi/180, 2*math.Pi)
+	return -d * d / (2 * sigma * sigma)
+}
+
+// triangulate 返回各投掷方向直线的最小二乘交点。
+func triangulate(throws []EyeThrow) (float64, float64, error) {
+	var a11, a12, a22, b1, b2 float64
+	for _, t := range throws {
+		yaw := t.Yaw * math.Pi / 180
+		// 直线的单位法向量
+		nx, nz := math.Cos(yaw), math.Sin(yaw)
+		c := nx*t.X + nz*t.Z
+		a11 += nx * nx
+		a12 += nx * nz
+		a22 += nz * nz
+		b1 += nx * c
+		b2 += nz * c
+	}
+	det := a11*a22 - a12*a12
+	if math.Abs(det) < 1e-9 {
+		return 0, 0, fmt.Errorf("eye throws are parallel")
+	}
+	return (b1*a22 - b2*a12) / det, (a11*b2 - a12*b1) / det, nil
+}
+
+// TriangulateStronghold 根据两次或以上的末影之眼投掷估计要塞位置，返回按概率从大到小排列的候选 chunk
+// （坐标为 chunk*16+4，与 GetStrongholds 一致）。先求各方向直线的交点，再在交点附近的 chunk 上
+// 结合测量误差和 StrongholdRings 给出的环分布计算后验概率。
+func TriangulateStronghold(mc int, throws []EyeThrow) ([]StrongholdEstimate, error) {
+	if len(throws) < 2 {
+		return nil, fmt.Errorf("need at least 2 eye throws, got %d", len(throws))
+	}
+	x, z, err := triangulate(throws)
+	if err != nil {
+		return nil, err
+	}
+	// 搜索窗口：交点处 3 倍标准差对应的横向误差，加上生物群系校正的 7 chunk
+	rc := 8.0
+	for _, t := range throws {
+		sigma := math.Max(t.Error, 0.1) * math.Pi / 180
+		rc = math.Max(rc, math.Hypot(x-t.X, z-t.Z)*math.Tan(3*sigma)/16+7)
+	}
+	rc = math.Min(rc, 96)
+
+	rings := StrongholdRings(mc)
+	cx0, cz0 := floorDiv(int(math.Floor(x)), 16), floorDiv(int(math.Floor(z)), 16)
+	r := int(math.Ceil(rc))
+	var out []StrongholdEstimate
+	var logs []float64
+	best := math.Inf(-1)
+	for cz := cz0 - r; cz <= cz0+r; cz++ {
+		for cx := cx0 - r; cx <= cx0+r; cx++ {
+			prior := strongholdPrior(rings, cx, cz)
+			if prior <= 0 {
+				continue
+			}
+			tx, tz := eyeTarget(cx, cz)
+			l := math.Log(prior)
+			for _, t := range throws {
+				l += t.logLikelihood(tx, tz)
+			}
+			out = append(out, StrongholdEstimate{Pos: Pos{int(tx), int(tz)}})
+			logs = append(logs, l)
+			best = math.Max(best, l)
+		}
+	}
+	if len(out) == 0 {
+		return nil, fmt.Errorf("no stronghold ring intersects the triangulated area")
+	}
+	return normalizeEstimates(out, logs, best), nil
+}
+
+// strongholdPrior 返回 chunk (cx, cz) 处的要塞先验密度：落在某一环（含生物群系校正的 7 chunk 余量）内时
+// 为该环的要塞数除以环的面积，否则为 0。
+func strongholdPrior(rings []StrongholdRing, cx, cz int) float64 {
+	const snap = 7
+	d := math.Hypot(float64(cx), float64(cz))
+	p := 0.0
+	for _, ring := range rings {
+		lo, hi := math.Max(ring.MinDist-snap, 0), ring.MaxDist+snap
+		if d >= lo && d <= hi {
+			p += float64(ring.Count) / (math.Pi * (hi*hi - lo*lo))
+		}
+	}
+	return p
+}
+
+// PredictStronghold 在种子已知时根据末影之眼投掷预测要塞：候选为 GetStrongholds 给出的实际要塞，
+// 按投掷的似然计算概率，从大到小返回。gen 需要已经 ApplySeed 到主世界。
+func (gen *Generator) PredictStronghold(throws []EyeThrow) ([]StrongholdEstimate, error) {
+	if len(throws) == 0 {
+		return nil, fmt.Errorf("no eye throws")
+	}
+	strongholds, err := gen.GetStrongholds(0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]StrongholdEstimate, len(strongholds))
+	logs := make([]float64, len(strongholds))
+	best := math.Inf(-1)
+	for i, p := range strongholds {
+		out[i].Pos = p
+		for _, t := range throws {
+			logs[i] += t.logLikelihood(float64(p.X), float64(p.Z))
+		}
+		best = math.Max(best, logs[i])
+	}
+	return normalizeEstimates(out, logs, best), nil
+}
+
+// normalizeEstimates 把对数概率归一化，按概率从大到小排序并去掉可以忽略的候选。
+func normalizeEstimates(out []StrongholdEstimate, logs []float64, best float64) []StrongholdEstimate {
+	sum := 0.0
+	for i := range out {
+		out[i].Probability = math.Exp(logs[i] - best)
+		sum += out[i].Probability
+	}
+	n := 0
+	for i := range out {
+		out[i].Probability /= sum
+		if out[i].Probability >= 1e-6 {
+			out[n] = out[i]
+			n++
+		}
+	}
+	out = out[:n]
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Probability > out[j].Probability
+	})
+	return out
+}
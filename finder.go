@@ -2,6 +2,7 @@ package gobiomes
 
 import (
 	"fmt"
+	"math"
 )
 
 // StructureConfig 对应 cubiomes 的 StructureConfig。
@@ -11,7 +12,7 @@ type StructureConfig struct {
 	ChunkRange int
 	StructType StructureType
 	Dim        Dimension
-	Rarity     float32
+	Rarity     float32 // structure_set 的 frequency（装饰特征为 rarity），0 表示不削减，方法见 Finder.frequencyReduction
 }
 
 // Finder 是对结构定位相关逻辑的封装。
@@ -26,6 +27,9 @@ func NewFinder(version int) *Finder {
 
 // GetStructureConfig 返回指定结构在该版本下的配置。
 func (f *Finder) GetStructureConfig(st StructureType) (StructureConfig, error) {
+	if cs, ok := GetCustomStructure(st); ok {
+		return cs.config(st), nil
+	}
 	mc := f.Version
 	var sconf StructureConfig
 	found := false
@@ -42,7 +46,7 @@ func (f *Finder) GetStructureConfig(st StructureType) (StructureConfig, error) {
 	s_igloo := StructureConfig{14357618, 32, 24, Igloo, 0, 0}
 	s_jungle_temple := StructureConfig{14357619, 32, 24, JunglePyramid, 0, 0}
 	s_swamp_hut := StructureConfig{14357620, 32, 24, SwampHut, 0, 0}
-	s_outpost := StructureConfig{165745296, 32, 24, Outpost, 0, 0}
+	s_outpost := StructureConfig{165745296, 32, 24, Outpost, 0, 0.2}
 	s_village_117 := StructureConfig{10387312, 32, 24, Village, 0, 0}
 	s_village := StructureConfig{10387312, 34, 26, Village, 0, 0}
 	s_ocean_ruin := StructureConfig{14357621, 20, 12, OceanRuin, 0, 0}
@@ -56,8 +60,8 @@ func (f *Finder) GetStructureConfig(st StructureType) (StructureConfig, error) {
 	s_ancient_city := StructureConfig{20083232, 24, 16, AncientCity, 0, 0}
 	s_trail_ruins := StructureConfig{83469867, 34, 26, TrailRuins, 0, 0}
 	s_trial_chambers := StructureConfig{94251327, 34, 22, TrialChambers, 0, 0}
-	s_treasure := StructureConfig{10387320, 1, 1, Treasure, 0, 0}
-	s_mineshaft := StructureConfig{0, 1, 1, Mineshaft, 0, 0}
+	s_treasure := StructureConfig{10387320, 1, 1, Treasure, 0, 0.01}
+	s_mineshaft := StructureConfig{0, 1, 1, Mineshaft, 0, 0.004}
 	s_desert_well_115 := StructureConfig{30010, 1, 1, DesertWell, 0, 1.0 / 1000.0}
 	s_desert_well_117 := StructureConfig{40013, 1, 1, DesertWell, 0, 1.0 / 1000.0}
 	s_desert_well := StructureConfig{40002, 1, 1, DesertWell, 0, 1.0 / 1000.0}
@@ -265,6 +269,32 @@ func getLargeStructurePos(config StructureConfig, seed uint64, regX, regZ int) P
 	}
 }
 
+// getDecorationPos 计算以 chunk 为单位的装饰特征（沙漠水井、紫水晶晶洞）的位置：
+// 特征随机数为装饰种子加上 salt（特征序号 + 10000*step），先按 rarity 判定是否生成，再取 chunk 内偏移。
+// 返回的 Y 仅对晶洞有意义，沙漠水井的 Y 由高度图决定。
+func getDecorationPos(mc int, config StructureConfig, seed uint64, chunkX, chunkZ int) (Pos3, bool) {
+	w := NewDecorationRandom(mc)
+	x, z := chunkX*16, chunkZ*16
+	w.SetSeed(w.SetDecorationSeed(seed, x, z) + uint64(config.Salt))
+	if w.NextFloat() >= float32(config.Rarity) {
+		return Pos3{}, false
+	}
+	p := Pos3{X: x + w.NextInt(16), Z: z + w.NextInt(16)}
+	if config.StructType == Geode {
+		lo, hi := geodeYRange(mc)
+		p.Y = lo + w.NextInt(hi-lo+1)
+	}
+	return p, true
+}
+
+// geodeYRange 返回紫水晶晶洞的生成高度范围（含两端）。
+func geodeYRange(mc int) (int, int) {
+	if mc >= MC_1_18 {
+		return -58, 30
+	}
+	return 6, 46
+}
+
 // setAttemptSeed 对应 finders.c 中的 setAttemptSeed。
 func setAttemptSeed(s *uint64, cx, cz int) {
 	*s ^= uint64(cx>>4) ^ (uint64(cz>>4) << 4)
@@ -273,23 +303,24 @@ func setAttemptSeed(s *uint64, cx, cz int) {
 	*s = (*s*0x5deece66d + 0xb) & mask48
 }
 
-// ChunkGenerateRnd 返回 chunk 生成用的 48-bit RNG seed。
+// ChunkGenerateRnd 返回 chunk 生成用的 48-bit RNG seed（即 setLargeFeatureSeed 之后的状态）。
 func (f *Finder) ChunkGenerateRnd(worldSeed uint64, chunkX, chunkZ int) uint64 {
-	r := NewRng(worldSeed)
-	a := uint64(r.NextLong())
-	b := uint64(r.NextLong())
-	rnd := (a*uint64(chunkX) ^ b*uint64(chunkZ) ^ worldSeed) & mask48
-	r.SetSeed(rnd)
-	return r.seed
+	w := &WorldgenRandom{}
+	w.SetLargeFeatureSeed(worldSeed, chunkX, chunkZ)
+	return w.r.seed
 }
 
 // GetStructurePos 返回结构在指定 region 内的生成尝试位置。
+// 只依赖种子的结构集规则会被应用：下界要塞与堡垒遗迹共享一次加权选择，1.16+ 的前哨站不会在村庄附近生成。
+// 1.12 及以前各神庙共享 Feature 的生成尝试，实际类型由生物群系决定，见 Generator.ResolveFeature。
 func (f *Finder) GetStructurePos(st StructureType, seed uint64, regX, regZ int) (*Pos, error) {
 	config, err := f.GetStructureConfig(st)
 	if err != nil {
 		return nil, err
 	}
 
+	// 1.18+ 的装饰种子使用 Xoroshiro，需要完整的 64 位种子
+	worldSeed := seed
 	seed &= mask48
 	var pos Pos
 
@@ -322,26 +353,37 @@ func (f *Finder) GetStructurePos(st StructureType, seed uint64, regX, regZ int)
 
 	case Outpost:
 		pos = getFeaturePos(config, seed, regX, regZ)
-		s := seed
-		setAttemptSeed(&s, pos.X>>4, pos.Z>>4)
-		// nextInt(&s, 5) == 0
-		r := &Rng{seed: s}
-		if r.NextInt(5) == 0 {
-			return &pos, nil
+		if !f.isRarityChunk(config, seed, pos.X>>4, pos.Z>>4) {
+			return nil, nil
 		}
-		return nil, nil
+		if f.Version >= MC_1_16_1 {
+			// 10 chunk 内有村庄生成尝试时不生成；1.16 以前还要求村庄能够生成，见 Generator.IsViableStructurePos
+			villages, err := f.villageAttemptsNear(seed, pos.X>>4, pos.Z>>4, outpostVillageExclusion)
+			if err != nil {
+				return nil, err
+			}
+			if len(villages) > 0 {
+				return nil, nil
+			}
+		}
+		return &pos, nil
 
 	case Treasure:
-		pos.X = regX*16 + 9
-		pos.Z = regZ*16 + 9
-		s := uint64(regX)*341873128712 + uint64(regZ)*132897987541 + seed + uint64(config.Salt)
-		r := NewRng(s)
-		if r.NextFloat() < 0.01 {
-			return &pos, nil
+		// region 大小为 1 个 chunk，regX/regZ 即 chunk 坐标
+		if !f.isRarityChunk(config, seed, regX, regZ) {
+			return nil, nil
 		}
-		return nil, nil
+		pos = Pos{X: regX*16 + 9, Z: regZ*16 + 9}
+		return &pos, nil
 
 	case Mineshaft:
+		if f.Version >= MC_1_16_1 {
+			if !f.isRarityChunk(config, seed, regX, regZ) {
+				return nil, nil
+			}
+			pos = Pos{X: regX * 16, Z: regZ * 16}
+			return &pos, nil
+		}
 		res := f.GetMineshafts(seed, regX, regZ, 1, 1, 1)
 		if len(res) > 0 {
 			return &res[0], nil
@@ -350,8 +392,14 @@ func (f *Finder) GetStructurePos(st StructureType, seed uint64, regX, regZ int)
 
 	case Fortress:
 		if f.Version >= MC_1_18 {
+			// 与堡垒遗迹共享生成尝试，由一次加权选择决定；选中的堡垒遗迹位于玄武岩三角洲时原版改为下界要塞，
+			// 本包不采样下界生物群系，不处理这种情况
 			pos = getFeaturePos(config, seed, regX, regZ)
-			return &pos, nil
+			r := &Rng{seed: f.ChunkGenerateRnd(seed, pos.X>>4, pos.Z>>4)}
+			if sel, _ := selectNetherComplex(r, func(StructureType) bool { return true }); sel == Fortress {
+				return &pos, nil
+			}
+			return nil, nil
 		} else if f.Version >= MC_1_16_1 {
 			// getRegPos 逻辑
 			s := seed + uint64(regX)*341873128712 + uint64(regZ)*132897987541 + uint64(config.Salt)
@@ -378,9 +426,8 @@ func (f *Finder) GetStructurePos(st StructureType, seed uint64, regX, regZ int)
 	case Bastion:
 		if f.Version >= MC_1_18 {
 			pos = getFeaturePos(config, seed, regX, regZ)
-			s := f.ChunkGenerateRnd(seed, pos.X>>4, pos.Z>>4)
-			r := &Rng{seed: s}
-			if r.NextInt(5) >= 2 {
+			r := &Rng{seed: f.ChunkGenerateRnd(seed, pos.X>>4, pos.Z>>4)}
+			if sel, _ := selectNetherComplex(r, func(StructureType) bool { return true }); sel == Bastion {
 				return &pos, nil
 			}
 			return nil, nil
@@ -395,21 +442,90 @@ func (f *Finder) GetStructurePos(st StructureType, seed uint64, regX, regZ int)
 			return nil, nil
 		}
 
+	case DesertWell, Geode:
+		p, ok := getDecorationPos(f.Version, config, worldSeed, regX, regZ)
+		if !ok {
+			return nil, nil
+		}
+		pos = Pos{p.X, p.Z}
+		return &pos, nil
+
 	case Stronghold:
-		return nil, fmt.Errorf("Stronghold search requires specialized logic (not region-based)")
+		return nil, fmt.Errorf("strongholds are not region based, use Generator.GetStrongholds")
 
 	default:
+		if cs, ok := GetCustomStructure(st); ok {
+			pos, ok := getCustomPos(cs, seed, regX, regZ)
+			if !ok {
+				return nil, nil
+			}
+			return &pos, nil
+		}
 		return nil, fmt.Errorf("GetStructurePos not implemented for %v in pure Go", st)
 	}
 }
 
 // GetMineshafts 在指定 chunk 范围内查找废弃矿井。
 func (f *Finder) GetMineshafts(seed uint64, chunkX, chunkZ, chunkW, chunkH, maxCount int) []Pos {
-	return getMineshaftsGo(f.Version, seed, chunkX, chunkZ, chunkX+chunkW-1, chunkZ+chunkH-1, maxCount)
+	config, err := f.GetStructureConfig(Mineshaft)
+	if err != nil {
+		return nil
+	}
+	return getMineshaftsGo(f.Version, config.Rarity, seed, chunkX, chunkZ, chunkX+chunkW-1, chunkZ+chunkH-1, maxCount)
+}
+
+// frequencyReduction 返回结构使用的频率削减方法，对应 structure_set 的 frequency_reduction_method。
+// 1.18 以前前哨站、埋藏的宝藏和废弃矿井的硬编码判定分别与 LEGACY_TYPE_1、LEGACY_TYPE_2、LEGACY_TYPE_3 相同。
+func (f *Finder) frequencyReduction(st StructureType) FrequencyReduction {
+	switch st {
+	case Outpost:
+		return FreqLegacyType1
+	case Treasure:
+		return FreqLegacyType2
+	case Mineshaft:
+		return FreqLegacyType3
+	}
+	if cs, ok := GetCustomStructure(st); ok {
+		return cs.Reduction
+	}
+	return FreqDefault
+}
+
+// isRarityChunk 判断结构在 chunk (chunkX, chunkZ) 的生成尝试是否通过 config.Rarity 的频率削减，
+// Rarity 为 0 时总是通过。
+func (f *Finder) isRarityChunk(config StructureConfig, seed uint64, chunkX, chunkZ int) bool {
+	return frequencyReductionPass(f.frequencyReduction(config.StructType), config.Rarity, seed, chunkX, chunkZ, config.Salt)
+}
+
+// GetBuriedTreasures 在指定 chunk 范围内查找埋藏的宝藏，返回箱子所在的 block 坐标（chunk 坐标 *16 + 9）。
+// 结果未检查生物群系，需要配合 IsViableStructurePos 判断是否位于沙滩。
+func (f *Finder) GetBuriedTreasures(seed uint64, chunkX, chunkZ, chunkW, chunkH, maxCount int) []Pos {
+	config, err := f.GetStructureConfig(Treasure)
+	if err != nil {
+		return nil
+	}
+	var out []Pos
+	for i := chunkX; i < chunkX+chunkW; i++ {
+		for j := chunkZ; j < chunkZ+chunkH; j++ {
+			if !f.isRarityChunk(config, seed, i, j) {
+				continue
+			}
+			out = append(out, Pos{X: i*16 + 9, Z: j*16 + 9})
+			if len(out) >= maxCount {
+				return out
+			}
+		}
+	}
+	return out
 }
 
 // getMineshaftsGo 内部实现。
-func getMineshaftsGo(mc int, seed uint64, cx0, cz0, cx1, cz1, nout int) []Pos {
+// rarity 为 1.16+ 的 float 概率，1.16 以前的概率是 double 类型的 0.004。
+func getMineshaftsGo(mc int, rarity float32, seed uint64, cx0, cz0, cx1, cz1, nout int) []Pos {
+	prob := 0.004
+	if mc >= MC_1_16_1 {
+		prob = float64(rarity)
+	}
 	r := NewRng(seed)
 	a := uint64(r.NextLong())
 	b := uint64(r.NextLong())
@@ -420,7 +536,7 @@ func getMineshaftsGo(mc int, seed uint64, cx0, cz0, cx1, cz1, nout int) []Pos {
 		for j := cz0; j <= cz1; j++ {
 			r.SetSeed(aix ^ uint64(j)*b)
 			if mc >= MC_1_13 {
-				if r.NextDouble() < 0.004 {
+				if r.NextDouble() < prob {
 					out = append(out, Pos{X: i * 16, Z: j * 16})
 					if len(out) >= nout {
 						return out
@@ -451,3 +567,161 @@ func getMineshaftsGo(mc int, seed uint64, cx0, cz0, cx1, cz1, nout int) []Pos {
 	}
 	return out
 }
+
+// StrongholdRing 描述一环要塞：数量以及生物群系校正前到原点的 chunk 距离范围。
+type StrongholdRing struct {
+	Count            int
+	MinDist, MaxDist float64
+}
+
+// StrongholdRings 返回该版本要塞环的分布。1.9 以前只有 3 个要塞，1.9+ 共 128 个，分布在 8 环上。
+func StrongholdRings(mc int) []StrongholdRing {
+	if mc < MC_1_9 {
+		return []StrongholdRing{{3, 1.25 * 32, 2.25 * 32}}
+	}
+	const distance, count = 32, 128
+	var rings []StrongholdRing
+	spread := 3
+	for n, k := 0, 0; n < count; k++ {
+		c := float64(4*distance + distance*k*6)
+		rings = append(rings, StrongholdRing{min(spread, count-n), c - 1.25*distance, c + 1.25*distance})
+		n += spread
+		// 与原版一致，最后一环的角度间隔按 10 个计算，但只生成 9 个
+		spread += 2 * spread / (k + 2)
+		spread = min(spread, count-n+1)
+	}
+	return rings
+}
+
+// isStrongholdBiome 判断要塞位置的生物群系校正是否可以选择生物群系 b。
+// 1.18+ 对应 has_structure/stronghold（1.19+ 为 stronghold_biased_to）标签：排除海洋、河流、沙滩、
+// 沼泽、石岸、深暗之域和红树林沼泽。1.18 的采样结果对改名的生物群系使用旧 ID，因此新旧名称都列出。
+func isStrongholdBiome(mc int, b Biome) bool {
+	if mc >= MC_1_18 {
+		switch b {
+		case Plains, SunflowerPlains, SnowyPlains, SnowyTundra, IceSpikes, Desert,
+			Forest, FlowerForest, BirchForest, DarkForest,
+			OldGrowthBirchForest, TallBirchForest, OldGrowthPineTaiga, GiantTreeTaiga,
+			OldGrowthSpruceTaiga, GiantSpruceTaiga, Taiga, SnowyTaiga, Savanna, SavannaPlateau,
+			WindsweptHills, Mountains, WindsweptGravellyHills, GravellyMountains,
+			WindsweptForest, WoodedMountains, WindsweptSavanna, ShatteredSavanna,
+			Jungle, SparseJungle, JungleEdge, BambooJungle,
+			Badlands, ErodedBadlands, WoodedBadlands, WoodedBadlandsPlateau,
+			Meadow, Grove, SnowySlopes, FrozenPeaks, JaggedPeaks, StonyPeaks,
+			MushroomFields, DripstoneCaves, LushCaves:
+			return true
+		case PaleGarden:
+			return mc >= MC_1_21_WD
+		}
+		return false
+	}
+	if b.IsOceanic() {
+		return false
+	}
+	switch b {
+	case Plains, MushroomFields, TaigaHills:
+		return mc >= MC_1_7
+	case Swamp:
+		return mc <= MC_1_6
+	case River, FrozenRiver, Beach, SnowyBeach, SwampHills, MushroomFieldShore:
+		return false
+	}
+	return true
+}
+
+// javaRound 对应 Java 的 Math.round。
+func javaRound(x float64) int {
+	return int(math.Floor(x + 0.5))
+}
+
+// GetStrongholds 返回种子的前 count 个要塞位置（按生成顺序，count <= 0 时返回全部），
+// 坐标为要塞起始 chunk 的 (chunk*16+4, chunk*16+4)。gen 需要已经 ApplySeed 到主世界。
+// 每个要塞都要在 112 格范围内做一次生物群系校正，数量较多时较慢。
+func (gen *Generator) GetStrongholds(count int) ([]Pos, error) {
+	if gen.Version < MC_B1_8 {
+		return nil, fmt.Errorf("strongholds not supported in version %v", gen.Version)
+	}
+	if gen.Dim != DimOverworld {
+		return nil, fmt.Errorf("strongholds require an overworld generator")
+	}
+	total := 128
+	if gen.Version < MC_1_9 {
+		total = 3
+	}
+	if count <= 0 || count > total {
+		count = total
+	}
+
+	r := NewRng(gen.Seed)
+	angle := r.NextDouble() * math.Pi * 2
+	out := make([]Pos, 0, count)
+	ring, idx, spread := 0, 0, 3
+	for i := 0; i < count; i++ {
+		var dist float64
+		if gen.Version < MC_1_9 {
+			dist = (1.25 + r.NextDouble()) * 32
+		} else {
+			dist = float64(4*32+32*ring*6) + (r.NextDouble()-0.5)*32*2.5
+		}
+		cx := javaRound(math.Cos(angle) * dist)
+		cz := javaRound(math.Sin(angle) * dist)
+		search := r
+		if gen.Version > MC_1_19_2 {
+			// 1.19.3+ 每个要塞使用 fork 出的随机数做生物群系搜索
+			search = NewRng(uint64(r.NextLong()))
+		}
+		if p, ok := gen.locateStrongholdBiome((cx<<4)+8, (cz<<4)+8, 112, search); ok {
+			cx, cz = p.X>>4, p.Z>>4
+		}
+		out = append(out, Pos{X: cx<<4 + 4, Z: cz<<4 + 4})
+
+		angle += 2 * math.Pi / float64(spread)
+		if idx++; idx == spread && gen.Version >= MC_1_9 {
+			ring, idx = ring+1, 0
+			spread += 2 * spread / (ring + 1)
+			spread = min(spread, total-i)
+			angle += r.NextDouble() * math.Pi * 2
+		}
+	}
+	return out, nil
+}
+
+// locateStrongholdBiome 在以 (x, z) 为中心、半径 radius 的正方形内按 1:4 分辨率随机选择一个要塞生物群系，
+// 对应 BiomeSource.findBiomeHorizontal（1.16 以前为 findBiomePosition）。
+func (gen *Generator) locateStrongholdBiome(x, z, radius int, r *Rng) (Pos, bool) {
+	x1, z1 := (x-radius)>>2, (z-radius)>>2
+	x2, z2 := (x+radius)>>2, (z+radius)>>2
+	w, h := x2-x1+1, z2-z1+1
+	ids := make([]int, w*h)
+	if gen.Version >= MC_1_18 {
+		for j := 0; j < h; j++ {
+			for i := 0; i < w; i++ {
+				ids[j*w+i] = int(gen.GetBiomeAt(4, x1+i, 0, z1+j))
+			}
+		}
+	} else {
+		e := gen.LS.Entry4
+		if e == nil || e.GetMap(e, ids, x1, z1, w, h) != 0 {
+			return Pos{}, false
+		}
+	}
+
+	var p Pos
+	found := 0
+	for i, id := range ids {
+		if !isStrongholdBiome(gen.Version, Biome(id)) {
+			continue
+		}
+		if found == 0 || r.NextInt(found+1) == 0 {
+			p = Pos{X: (x1 + i%w) * 4, Z: (z1 + i/w) * 4}
+			if gen.Version < MC_1_13 {
+				// 1.13 以前只有被选中时才增加计数
+				found++
+			}
+		}
+		if gen.Version >= MC_1_13 {
+			found++
+		}
+	}
+	return p, found > 0
+}
@@ -0,0 +1,377 @@
+package gobiomes
+
+// 下界要塞部件类型，对应 NetherBridgePieces 中的各部件类。
+const (
+	FortressStart           = iota // 起始十字桥
+	BridgeStraight                 // 直桥
+	BridgeCrossing                 // 十字桥
+	BridgeFortifiedCrossing        // 带顶十字路口 (RoomCrossing)
+	BridgeStairs                   // 桥上楼梯间
+	BridgeSpawner                  // 烈焰人刷怪笼平台 (MonsterThrone)
+	BridgeCorridorEntrance         // 城堡入口
+	CorridorStraight               // 小走廊
+	CorridorCrossing               // 走廊十字路口
+	CorridorTurnRight              // 走廊右转
+	CorridorTurnLeft               // 走廊左转
+	CorridorStairs                 // 走廊楼梯
+	CorridorTBalcony               // 走廊 T 型阳台
+	CorridorNetherWart             // 地狱疣种植室 (CastleStalkRoom)
+	FortressEnd                    // 桥的断头 (BridgeEndFiller)
+)
+
+// fortressPieceInfo 记录部件名称和 BoundingBox.orientBox 使用的偏移与尺寸。
+var fortressPieceInfo = [...]struct {
+	name                 string
+	offX, offY, offZ     int
+	width, height, depth int
+}{
+	FortressStart:           {"fortress_start", -8, -3, 0, 19, 10, 19},
+	BridgeStraight:          {"bridge_straight", -1, -3, 0, 5, 10, 19},
+	BridgeCrossing:          {"bridge_crossing", -8, -3, 0, 19, 10, 19},
+	BridgeFortifiedCrossing: {"bridge_fortified_crossing", -2, 0, 0, 7, 9, 7},
+	BridgeStairs:            {"bridge_stairs", -2, 0, 0, 7, 11, 7},
+	BridgeSpawner:           {"bridge_spawner", -2, 0, 0, 7, 8, 9},
+	BridgeCorridorEntrance:  {"bridge_corridor_entrance", -5, -3, 0, 13, 14, 13},
+	CorridorStraight:        {"corridor_straight", -1, 0, 0, 5, 7, 5},
+	CorridorCrossing:        {"corridor_crossing", -1, 0, 0, 5, 7, 5},
+	CorridorTurnRight:       {"corridor_turn_right", -1, 0, 0, 5, 7, 5},
+	CorridorTurnLeft:        {"corridor_turn_left", -1, 0, 0, 5, 7, 5},
+	CorridorStairs:          {"corridor_stairs", -1, -7, 0, 5, 14, 10},
+	CorridorTBalcony:        {"corridor_t_balcony", -3, 0, 0, 9, 7, 9},
+	CorridorNetherWart:      {"corridor_nether_wart", -5, -3, 0, 13, 14, 13},
+	FortressEnd:             {"fortress_end", -1, -3, 0, 5, 10, 8},
+}
+
+// 水平朝向，顺序与 Direction.Plane.HORIZONTAL 一致，数值同时等于相对北向的顺时针旋转。
+const (
+	dirNorth = 0
+	dirEast  = 1
+	dirSouth = 2
+	dirWest  = 3
+)
+
+// orientBox 对应 BoundingBox.orientBox。
+func orientBox(x, y, z, offX, offY, offZ, width, height, depth, dir int) BoundingBox {
+	switch dir {
+	case dirNorth:
+		return BoundingBox{x + offX, y + offY, z - depth + 1 + offZ, x + width - 1 + offX, y + height - 1 + offY, z + offZ}
+	case dirWest:
+		return BoundingBox{x - depth + 1 + offZ, y + offY, z + offX, x + offZ, y + height - 1 + offY, z + width - 1 + offX}
+	case dirEast:
+		return BoundingBox{x + offZ, y + offY, z + offX, x + depth - 1 + offZ, y + height - 1 + offY, z + width - 1 + offX}
+	}
+	return BoundingBox{x + offX, y + offY, z + offZ, x + width - 1 + offX, y + height - 1 + offY, z + depth - 1 + offZ}
+}
+
+// pieceWorldPos 对应 StructurePiece.getWorldX/Y/Z，将部件局部坐标转换为 block 坐标。
+func pieceWorldPos(p *Piece, x, y, z int) Pos3 {
+	bb := p.BB
+	out := Pos3{Y: bb.MinY + y}
+	switch p.Rotation {
+	case dirNorth:
+		out.X, out.Z = bb.MinX+x, bb.MaxZ-z
+	case dirSouth:
+		out.X, out.Z = bb.MinX+x, bb.MinZ+z
+	case dirWest:
+		out.X, out.Z = bb.MaxX-z, bb.MinZ+x
+	case dirEast:
+		out.X, out.Z = bb.MinX+z, bb.MinZ+x
+	}
+	return out
+}
+
+// findCollisionPiece 对应 StructurePiece.findCollisionPiece，返回第一个与 bb 相交的部件序号，没有则返回 -1。
+func findCollisionPiece(pieces []Piece, bb BoundingBox) int {
+	for i := range pieces {
+		if pieces[i].BB.Intersects(bb) {
+			return i
+		}
+	}
+	return -1
+}
+
+// fortressWeight 对应 NetherBridgePieces.PieceWeight。
+type fortressWeight struct {
+	typ        int
+	weight     int
+	maxCount   int
+	placed     int
+	allowInRow bool
+}
+
+func (w *fortressWeight) isValid() bool {
+	return w.maxCount == 0 || w.placed < w.maxCount
+}
+
+var (
+	fortressBridgeWeights = []fortressWeight{
+		{BridgeStraight, 30, 0, 0, true},
+		{BridgeCrossing, 10, 4, 0, false},
+		{BridgeFortifiedCrossing, 10, 4, 0, false},
+		{BridgeStairs, 10, 3, 0, false},
+		{BridgeSpawner, 5, 2, 0, false},
+		{BridgeCorridorEntrance, 5, 1, 0, false},
+	}
+	fortressCastleWeights = []fortressWeight{
+		{CorridorStraight, 25, 0, 0, true},
+		{CorridorCrossing, 15, 5, 0, false},
+		{CorridorTurnRight, 5, 10, 0, false},
+		{CorridorTurnLeft, 5, 10, 0, false},
+		{CorridorStairs, 10, 3, 0, true},
+		{CorridorTBalcony, 7, 2, 0, false},
+		{CorridorNetherWart, 5, 2, 0, false},
+	}
+)
+
+// fortressGen 保存要塞部件生成过程中的状态，对应 NetherBridgePieces.StartPiece。
+type fortressGen struct {
+	r       *Rng
+	pieces  []Piece
+	pending []int
+	bridge  []fortressWeight
+	castle  []fortressWeight
+	prev    int
+	startX  int
+	startZ  int
+}
+
+// createPiece 对应各部件的 createPiece：包围盒合法且不与已有部件相交时创建部件。
+func (g *fortressGen) createPiece(typ, x, y, z, dir, depth int) *Piece {
+	info := fortressPieceInfo[typ]
+	bb := orientBox(x, y, z, info.offX, info.offY, info.offZ, info.width, info.height, info.depth, dir)
+	if bb.MinY <= 10 || findCollisionPiece(g.pieces, bb) >= 0 {
+		return nil
+	}
+	switch typ {
+	case FortressEnd:
+		g.r.Next(32) // selfSeed
+	case CorridorTurnRight, CorridorTurnLeft:
+		g.r.NextInt(3) // isNeedingChest
+	}
+	return &Piece{Name: info.name, Type: typ, Pos: Pos3{x, y, z}, BB: bb, Rotation: dir, Depth: depth}
+}
+
+// updatePieceWeight 对应 NetherBridgePiece.updatePieceWeight。
+func updatePieceWeight(weights []fortressWeight) int {
+	limited := false
+	total := 0
+	for _, w := range weights {
+		if w.maxCount > 0 && w.placed < w.maxCount {
+			limited = true
+		}
+		total += w.weight
+	}
+	if !limited {
+		return -1
+	}
+	return total
+}
+
+// generatePiece 对应 NetherBridgePiece.generatePiece。
+func (g *fortressGen) generatePiece(weights *[]fortressWeight, x, y, z, dir, depth int) *Piece {
+	total := updatePieceWeight(*weights)
+	ok := total > 0 && depth <= 30
+attempts:
+	for n := 0; n < 5 && ok; n++ {
+		k := g.r.NextInt(total)
+		for i := range *weights {
+			w := &(*weights)[i]
+			if k -= w.weight; k >= 0 {
+				continue
+			}
+			if !w.isValid() || (w.typ == g.prev && !w.allowInRow) {
+				continue attempts
+			}
+			p := g.createPiece(w.typ, x, y, z, dir, depth)
+			if p == nil {
+				continue
+			}
+			w.placed++
+			g.prev = w.typ
+			if !w.isValid() {
+				*weights = append((*weights)[:i], (*weights)[i+1:]...)
+			}
+			return p
+		}
+	}
+	return g.createPiece(FortressEnd, x, y, z, dir, depth)
+}
+
+// generateAndAddPiece 对应 NetherBridgePiece.generateAndAddPiece。
+func (g *fortressGen) generateAndAddPiece(x, y, z, dir, depth int, castle bool) {
+	if absInt(x-g.startX) > 112 || absInt(z-g.startZ) > 112 {
+		// 超出范围时创建的断头部件不会加入要塞，但仍会消耗随机数
+		g.createPiece(FortressEnd, x, y, z, dir, depth)
+		return
+	}
+	weights := &g.bridge
+	if castle {
+		weights = &g.castle
+	}
+	if p := g.generatePiece(weights, x, y, z, dir, depth+1); p != nil {
+		g.pieces = append(g.pieces, *p)
+		g.pending = append(g.pending, len(g.pieces)-1)
+	}
+}
+
+func (g *fortressGen) childForward(p Piece, offX, offY int, castle bool) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth:
+		g.generateAndAddPiece(bb.MinX+offX, bb.MinY+offY, bb.MinZ-1, p.Rotation, p.Depth, castle)
+	case dirSouth:
+		g.generateAndAddPiece(bb.MinX+offX, bb.MinY+offY, bb.MaxZ+1, p.Rotation, p.Depth, castle)
+	case dirWest:
+		g.generateAndAddPiece(bb.MinX-1, bb.MinY+offY, bb.MinZ+offX, p.Rotation, p.Depth, castle)
+	case dirEast:
+		g.generateAndAddPiece(bb.MaxX+1, bb.MinY+offY, bb.MinZ+offX, p.Rotation, p.Depth, castle)
+	}
+}
+
+func (g *fortressGen) childLeft(p Piece, offY, offXZ int, castle bool) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth, dirSouth:
+		g.generateAndAddPiece(bb.MinX-1, bb.MinY+offY, bb.MinZ+offXZ, dirWest, p.Depth, castle)
+	case dirWest, dirEast:
+		g.generateAndAddPiece(bb.MinX+offXZ, bb.MinY+offY, bb.MinZ-1, dirNorth, p.Depth, castle)
+	}
+}
+
+func (g *fortressGen) childRight(p Piece, offY, offXZ int, castle bool) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth, dirSouth:
+		g.generateAndAddPiece(bb.MaxX+1, bb.MinY+offY, bb.MinZ+offXZ, dirEast, p.Depth, castle)
+	case dirWest, dirEast:
+		g.generateAndAddPiece(bb.MinX+offXZ, bb.MinY+offY, bb.MaxZ+1, dirSouth, p.Depth, castle)
+	}
+}
+
+// addChildren 对应各部件的 addChildren。
+func (g *fortressGen) addChildren(p Piece) {
+	switch p.Type {
+	case FortressStart, BridgeCrossing:
+		g.childForward(p, 8, 3, false)
+		g.childLeft(p, 3, 8, false)
+		g.childRight(p, 3, 8, false)
+	case BridgeStraight:
+		g.childForward(p, 1, 3, false)
+	case BridgeFortifiedCrossing:
+		g.childForward(p, 2, 0, false)
+		g.childLeft(p, 0, 2, false)
+		g.childRight(p, 0, 2, false)
+	case BridgeStairs:
+		g.childRight(p, 6, 2, false)
+	case BridgeCorridorEntrance:
+		g.childForward(p, 5, 3, true)
+	case CorridorNetherWart:
+		g.childForward(p, 5, 3, true)
+		g.childForward(p, 5, 11, true)
+	case CorridorStraight, CorridorStairs:
+		g.childForward(p, 1, 0, true)
+	case CorridorCrossing:
+		g.childForward(p, 1, 0, true)
+		g.childLeft(p, 0, 1, true)
+		g.childRight(p, 0, 1, true)
+	case CorridorTurnRight:
+		g.childRight(p, 0, 1, true)
+	case CorridorTurnLeft:
+		g.childLeft(p, 0, 1, true)
+	case CorridorTBalcony:
+		off := 1
+		if p.Rotation == dirWest || p.Rotation == dirNorth {
+			off = 5
+		}
+		g.childLeft(p, 0, off, g.r.NextInt(8) > 0)
+		g.childRight(p, 0, off, g.r.NextInt(8) > 0)
+	}
+}
+
+// fortressStartRng 返回要塞 StructureStart 使用的随机数生成器。
+// 1.13 之前起始部件沿用 canSpawnStructureAtCoords 中判定位置所用的随机数；之后使用 chunk 生成种子。
+func (f *Finder) fortressStartRng(seed uint64, chunkX, chunkZ int) *Rng {
+	if f.Version <= MC_1_12 {
+		s := seed & mask48
+		setAttemptSeed(&s, chunkX, chunkZ)
+		r := &Rng{seed: s}
+		r.NextInt(3)
+		r.NextInt(8)
+		r.NextInt(8)
+		return r
+	}
+	return &Rng{seed: f.ChunkGenerateRnd(seed, chunkX, chunkZ)}
+}
+
+// GetFortressPieces 生成下界要塞在 chunk (chunkX, chunkZ) 处的全部部件，
+// 对应 NetherFortressFeature.FortressStart.generatePieces，包含最终的 moveInsideHeights(48, 70) 高度调整。
+func (f *Finder) GetFortressPieces(seed uint64, chunkX, chunkZ int) []Piece {
+	g := &fortressGen{
+		r:      f.fortressStartRng(seed, chunkX, chunkZ),
+		bridge: append([]fortressWeight(nil), fortressBridgeWeights...),
+		castle: append([]fortressWeight(nil), fortressCastleWeights...),
+		prev:   -1,
+	}
+	x := chunkX<<4 + 2
+	z := chunkZ<<4 + 2
+	dir := g.r.NextInt(4)
+	start := Piece{
+		Name:     fortressPieceInfo[FortressStart].name,
+		Type:     FortressStart,
+		Pos:      Pos3{x, 64, z},
+		BB:       BoundingBox{x, 64, z, x + 18, 73, z + 18},
+		Rotation: dir,
+	}
+	g.startX, g.startZ = x, z
+	g.pieces = append(g.pieces, start)
+	g.addChildren(start)
+
+	for len(g.pending) > 0 {
+		k := g.r.NextInt(len(g.pending))
+		idx := g.pending[k]
+		g.pending = append(g.pending[:k], g.pending[k+1:]...)
+		g.addChildren(g.pieces[idx])
+	}
+
+	bb := PiecesBoundingBox(g.pieces)
+	span := 70 - 48 + 1 - (bb.MaxY - bb.MinY + 1)
+	y := 48
+	if span > 1 {
+		y += g.r.NextInt(span)
+	}
+	dy := y - bb.MinY
+	for i := range g.pieces {
+		g.pieces[i].Pos.Y += dy
+		g.pieces[i].BB.MinY += dy
+		g.pieces[i].BB.MaxY += dy
+	}
+	return g.pieces
+}
+
+// PiecesBoundingBox 返回包含所有部件的最小包围盒。
+func PiecesBoundingBox(pieces []Piece) BoundingBox {
+	if len(pieces) == 0 {
+		return BoundingBox{}
+	}
+	bb := pieces[0].BB
+	for _, p := range pieces[1:] {
+		bb.MinX = min(bb.MinX, p.BB.MinX)
+		bb.MinY = min(bb.MinY, p.BB.MinY)
+		bb.MinZ = min(bb.MinZ, p.BB.MinZ)
+		bb.MaxX = max(bb.MaxX, p.BB.MaxX)
+		bb.MaxY = max(bb.MaxY, p.BB.MaxY)
+		bb.MaxZ = max(bb.MaxZ, p.BB.MaxZ)
+	}
+	return bb
+}
+
+// GetFortressSpawners 返回要塞部件中烈焰人刷怪笼的 block 坐标。
+func GetFortressSpawners(pieces []Piece) []Pos3 {
+	var out []Pos3
+	for i := range pieces {
+		if pieces[i].Type == BridgeSpawner {
+			out = append(out, pieceWorldPos(&pieces[i], 3, 5, 5))
+		}
+	}
+	return out
+}
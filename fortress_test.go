@@ -0,0 +1,45 @@
+package gobiomes
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestGetFortressSpawners(t *testing.T) {
+	tests := []struct {
+		mc       int
+		seed     uint64
+		regX     int
+		chunkX   int
+		chunkZ   int
+		pieces   int
+		spawners []Pos3
+	}{
+		{MC_1_12, 1, -1, -6, 9, 120, []Pos3{{-100, 63, 155}, {-85, 63, 133}}},
+		{MC_1_16_5, 12345, -1, -21, 8, 133, []Pos3{{-280, 76, 161}, {-263, 76, 152}}},
+		{MC_1_20_6, 42, 0, 0, 0, 90, []Pos3{{-23, 77, 30}, {-25, 77, 11}}},
+	}
+	for _, tt := range tests {
+		f := NewFinder(tt.mc)
+		pos, err := f.GetStructurePos(Fortress, tt.seed, tt.regX, 0)
+		if err != nil || pos == nil || pos.X>>4 != tt.chunkX || pos.Z>>4 != tt.chunkZ {
+			t.Errorf("mc %d seed %d: fortress at %v (%v), want chunk (%d, %d)", tt.mc, tt.seed, pos, err, tt.chunkX, tt.chunkZ)
+			continue
+		}
+		pieces := f.GetFortressPieces(tt.seed, tt.chunkX, tt.chunkZ)
+		spawners := GetFortressSpawners(pieces)
+		if len(pieces) != tt.pieces || !slices.Equal(spawners, tt.spawners) {
+			t.Errorf("mc %d seed %d: %d pieces, spawners %v; want %d, %v", tt.mc, tt.seed, len(pieces), spawners, tt.pieces, tt.spawners)
+			continue
+		}
+		for _, s := range spawners {
+			in := false
+			for _, p := range pieces {
+				in = in || p.Type == BridgeSpawner && p.BB.Contains(s.X, s.Y, s.Z)
+			}
+			if !in {
+				t.Errorf("mc %d seed %d: spawner %v outside its platform", tt.mc, tt.seed, s)
+			}
+		}
+	}
+}
@@ -125,6 +125,15 @@ func (gen *Generator) GenBiomes(r Range) ([]int, error) {
 func (gen *Generator) IsViableStructurePos(stype StructureType, blockX, blockZ int, flags uint32) bool {
 	biome := gen.GetBiomeAt(1, blockX, 64, blockZ)
 
+	if gen.Version <= MC_1_12 {
+		switch stype {
+		case Feature, DesertPyramid, JunglePyramid, SwampHut, Igloo:
+			// 各神庙共享 Feature 的生成尝试，实际类型由生物群系决定
+			t, ok := gen.ResolveFeature(blockX, blockZ)
+			return ok && (stype == Feature || t == stype)
+		}
+	}
+
 	switch stype {
 	case Village:
 		if gen.Version >= MC_1_18 {
@@ -148,11 +157,29 @@ func (gen *Generator) IsViableStructurePos(stype StructureType, blockX, blockZ i
 	case Mansion:
 		return biome == DarkForest || biome == DarkForestHills
 	case Outpost:
+		if gen.Version <= MC_1_15 {
+			// 1.16 以前只有能够生成的村庄才会排除附近的前哨站
+			villages, err := NewFinder(gen.Version).villageAttemptsNear(gen.Seed&mask48, blockX>>4, blockZ>>4, outpostVillageExclusion)
+			if err != nil {
+				return false
+			}
+			for _, v := range villages {
+				if gen.IsViableStructurePos(Village, v.X, v.Z, 0) {
+					return false
+				}
+			}
+		}
 		return true
-	case Fortress:
-		return gen.Dim == DimNether
-	case Bastion:
-		return gen.Dim == DimNether && biome != BasaltDeltas
+	case Fortress, Bastion:
+		// 下界生物群系不被采样（biome 为 None），玄武岩三角洲的检查不会生效
+		if gen.Dim != DimNether {
+			return false
+		}
+		if gen.Version >= MC_1_18 {
+			sel, ok := gen.netherComplexAt(blockX, blockZ, biome)
+			return ok && sel == stype
+		}
+		return stype == Fortress || biome != BasaltDeltas
 	case EndCity:
 		return gen.Dim == DimEnd && biome == EndHighlands
 	case AncientCity:
@@ -161,6 +188,48 @@ func (gen *Generator) IsViableStructurePos(stype StructureType, blockX, blockZ i
 		return biome == Taiga || biome == SnowyTaiga || biome == OldGrowthBirchForest || biome == OldGrowthPineTaiga || biome == OldGrowthSpruceTaiga || biome == Jungle
 	case TrialChambers:
 		return true
+	case Treasure:
+		// 原版在 chunk 中心采样 1:4 生物群系；1.18+ 的高度取海平面附近
+		qx, qz := (blockX>>4)<<2+2, (blockZ>>4)<<2+2
+		b := gen.GetBiomeAt(4, qx, 63>>2, qz)
+		return b == Beach || b == SnowyBeach
+	case DesertWell:
+		if gen.Version >= MC_1_18 {
+			return biome == Desert
+		}
+		return biome == Desert || biome == DesertHills || biome == DesertLakes
+	case Geode:
+		// 任意高度的晶洞都可以生成；需要限定高度时使用 IsViableGeode
+		lo, hi := geodeYRange(gen.Version)
+		return gen.IsViableGeode(blockX, blockZ, lo, hi)
+	}
+	if cs, ok := GetCustomStructure(stype); ok {
+		if gen.Dim != cs.Dim {
+			return false
+		}
+		if len(cs.Biomes) == 0 {
+			return true
+		}
+		for _, b := range cs.Biomes {
+			if biome == b {
+				return true
+			}
+		}
+		return false
 	}
 	return true
 }
+
+// IsViableGeode 判断 block 坐标 (x, z) 是否为所在 chunk 的紫水晶晶洞位置，且晶洞高度在 [yMin, yMax] 内。
+// 晶洞生成时遇到空气或液体过多会放弃，这依赖地形，不在判断范围内。
+func (gen *Generator) IsViableGeode(x, z, yMin, yMax int) bool {
+	if gen.Dim != DimOverworld {
+		return false
+	}
+	config, err := NewFinder(gen.Version).GetStructureConfig(Geode)
+	if err != nil {
+		return false
+	}
+	p, ok := getDecorationPos(gen.Version, config, gen.Seed, x>>4, z>>4)
+	return ok && p.X == x && p.Z == z && p.Y >= yMin && p.Y <= yMax
+}
@@ -0,0 +1,716 @@
+package gobiomes
+
+import (
+	"fmt"
+	"sort"
+)
+
+// 拼图部件的投影方式，Piece.Type 取值。
+const (
+	JigsawRigid           = 0 // 保持相对父部件的高度
+	JigsawTerrainMatching = 1 // 贴合地形（例如村庄道路）
+)
+
+// JigsawPiece 是组装得到的一个拼图部件。Piece.Name 为元素的（第一个）模板名，
+// Piece.Pos 为模板原点的 block 坐标，Piece.Rotation 为模板的旋转。
+type JigsawPiece struct {
+	Piece
+	Element *JigsawElement
+}
+
+// Markers 返回部件中标记方块和实体的 block 坐标（已旋转和平移）。
+func (p *JigsawPiece) Markers(d *JigsawData) []JigsawMarker {
+	var out []JigsawMarker
+	var walk func(e *JigsawElement)
+	walk = func(e *JigsawElement) {
+		if t := d.Templates[e.Location]; e.Kind == JigsawSingle && t != nil {
+			for _, m := range t.Markers {
+				m.Pos = jigsawOffset(jigsawRotatePos(m.Pos, p.Rotation), p.Pos)
+				out = append(out, m)
+			}
+		}
+		for i := range e.Elements {
+			walk(&e.Elements[i])
+		}
+	}
+	walk(p.Element)
+	return out
+}
+
+// JigsawBoundingBox 返回所有拼图部件包围盒的并集。
+func JigsawBoundingBox(pieces []JigsawPiece) BoundingBox {
+	list := make([]Piece, len(pieces))
+	for i := range pieces {
+		list[i] = pieces[i].Piece
+	}
+	return PiecesBoundingBox(list)
+}
+
+// jigsawRotatePos 对应 StructureTemplate.transform（以原点为旋转中心，无镜像）。
+func jigsawRotatePos(p Pos3, rot int) Pos3 {
+	switch rot {
+	case 1:
+		return Pos3{-p.Z, p.Y, p.X}
+	case 2:
+		return Pos3{-p.X, p.Y, -p.Z}
+	case 3:
+		return Pos3{p.Z, p.Y, -p.X}
+	}
+	return p
+}
+
+func jigsawOffset(p, o Pos3) Pos3 {
+	return Pos3{p.X + o.X, p.Y + o.Y, p.Z + o.Z}
+}
+
+func jigsawRotateDir(d, rot int) int {
+	if d >= dirUp {
+		return d
+	}
+	return (d + rot) & 3
+}
+
+func jigsawOpposite(d int) int {
+	if d >= dirUp {
+		return dirUp + dirDown - d
+	}
+	return (d + 2) & 3
+}
+
+// jigsawStep 返回方向 d 的单位位移。
+func jigsawStep(d int) Pos3 {
+	switch d {
+	case dirNorth:
+		return Pos3{0, 0, -1}
+	case dirEast:
+		return Pos3{1, 0, 0}
+	case dirSouth:
+		return Pos3{0, 0, 1}
+	case dirWest:
+		return Pos3{-1, 0, 0}
+	case dirUp:
+		return Pos3{0, 1, 0}
+	}
+	return Pos3{0, -1, 0}
+}
+
+func bbMoved(bb BoundingBox, dx, dy, dz int) BoundingBox {
+	return BoundingBox{bb.MinX + dx, bb.MinY + dy, bb.MinZ + dz, bb.MaxX + dx, bb.MaxY + dy, bb.MaxZ + dz}
+}
+
+// jigsawBlock 是放置到世界中的拼图方块。
+type jigsawBlock struct {
+	pos        Pos3
+	front, top int
+	c          *JigsawConnector
+}
+
+// featureJigsaw 是 FeaturePoolElement 的默认拼图方块。
+var featureJigsaw = JigsawConnector{
+	Front: dirDown, Top: dirSouth, Rollable: true,
+	Name: "minecraft:bottom", Target: "minecraft:empty", Pool: "minecraft:empty",
+}
+
+// jigsawShuffle 对应 Util.shuffle 和 Collections.shuffle。
+func jigsawShuffle[T any](r *Rng, list []T) {
+	for i := len(list); i > 1; i-- {
+		j := r.NextInt(i)
+		list[i-1], list[j] = list[j], list[i-1]
+	}
+}
+
+// jigsawShuffleFastutil 对应 fastutil 的 ObjectArrays.shuffle，比 Util.shuffle 多一次 nextInt(1)。
+func jigsawShuffleFastutil[T any](r *Rng, list []T) {
+	for i := len(list) - 1; i >= 0; i-- {
+		j := r.NextInt(i + 1)
+		list[i], list[j] = list[j], list[i]
+	}
+}
+
+// jigsawAssembler 保存一次组装的状态，对应 JigsawPlacement.Placer。
+type jigsawAssembler struct {
+	mc      int
+	d       *JigsawData
+	r       *Rng
+	maxD    int
+	hack    bool
+	height  func(x, z int) int
+	aliases map[string]string
+	pieces  []JigsawPiece
+	queue   jigsawQueue
+	sizes   map[string]int // 模板池的最大高度，只在本次组装内缓存，JigsawData 可被并发共享
+}
+
+// jigsawFree 对应 Placer 中表示可用空间的 VoxelShape：外框减去已放置部件的包围盒。
+type jigsawFree struct {
+	outer BoundingBox
+	holes []BoundingBox
+}
+
+// fits 判断 bb（收缩 0.25 后）是否完全位于可用空间内。
+func (s *jigsawFree) fits(bb BoundingBox) bool {
+	o := s.outer
+	if bb.MinX < o.MinX || bb.MinY < o.MinY || bb.MinZ < o.MinZ || bb.MaxX > o.MaxX || bb.MaxY > o.MaxY || bb.MaxZ > o.MaxZ {
+		return false
+	}
+	for _, h := range s.holes {
+		if h.Intersects(bb) {
+			return false
+		}
+	}
+	return true
+}
+
+type jigsawState struct {
+	piece int
+	free  *jigsawFree
+	depth int
+}
+
+// jigsawQueue 对应 SequencedPriorityIterator：优先级高的先取出，同一优先级先进先出。
+// 1.20.3 以前所有部件的优先级都是 0，等同于普通队列。
+type jigsawQueue struct {
+	buckets map[int][]jigsawState
+}
+
+func (q *jigsawQueue) add(s jigsawState, priority int) {
+	if q.buckets == nil {
+		q.buckets = map[int][]jigsawState{}
+	}
+	q.buckets[priority] = append(q.buckets[priority], s)
+}
+
+func (q *jigsawQueue) next() (jigsawState, bool) {
+	best, found := 0, false
+	for p, b := range q.buckets {
+		if len(b) > 0 && (!found || p > best) {
+			best, found = p, true
+		}
+	}
+	if !found {
+		return jigsawState{}, false
+	}
+	s := q.buckets[best][0]
+	q.buckets[best] = q.buckets[best][1:]
+	return s, true
+}
+
+// poolName 对应 Placer.readPoolName：应用 pool_aliases。
+func (a *jigsawAssembler) poolName(name string) string {
+	if t, ok := a.aliases[name]; ok {
+		return t
+	}
+	return name
+}
+
+// pool 返回模板池，"minecraft:empty" 不在数据中时视为空池。
+func (a *jigsawAssembler) pool(name string) (*JigsawPool, bool) {
+	if p, ok := a.d.Pools[name]; ok {
+		return p, true
+	}
+	if name == "minecraft:empty" {
+		return &JigsawPool{Fallback: name}, true
+	}
+	return nil, false
+}
+
+// templates 返回模板池按权重展开后的元素列表，对应 StructureTemplatePool.templates。
+func (p *JigsawPool) templates() []*JigsawElement {
+	var out []*JigsawElement
+	for i := range p.Elements {
+		for w := 0; w < p.Elements[i].Weight; w++ {
+			out = append(out, &p.Elements[i])
+		}
+	}
+	return out
+}
+
+// shuffledTemplates 对应 StructureTemplatePool.getShuffledTemplates。
+func (a *jigsawAssembler) shuffledTemplates(p *JigsawPool) []*JigsawElement {
+	list := p.templates()
+	if a.mc >= MC_1_19_2 {
+		jigsawShuffle(a.r, list)
+	} else {
+		jigsawShuffleFastutil(a.r, list)
+	}
+	return list
+}
+
+// boundingBox 对应 StructurePoolElement.getBoundingBox。
+func (d *JigsawData) boundingBox(e *JigsawElement, pos Pos3, rot int) (BoundingBox, error) {
+	switch e.Kind {
+	case JigsawSingle:
+		t := d.Templates[e.Location]
+		if t == nil {
+			return BoundingBox{}, fmt.Errorf("missing template %q", e.Location)
+		}
+		a := jigsawRotatePos(Pos3{}, rot)
+		b := jigsawRotatePos(Pos3{t.Size.X - 1, t.Size.Y - 1, t.Size.Z - 1}, rot)
+		return BoundingBox{
+			min(a.X, b.X) + pos.X, min(a.Y, b.Y) + pos.Y, min(a.Z, b.Z) + pos.Z,
+			max(a.X, b.X) + pos.X, max(a.Y, b.Y) + pos.Y, max(a.Z, b.Z) + pos.Z,
+		}, nil
+	case JigsawList:
+		var bb BoundingBox
+		for i := range e.Elements {
+			sub, err := d.boundingBox(&e.Elements[i], pos, rot)
+			if err != nil {
+				return bb, err
+			}
+			if i == 0 {
+				bb = sub
+				continue
+			}
+			bb.MinX, bb.MinY, bb.MinZ = min(bb.MinX, sub.MinX), min(bb.MinY, sub.MinY), min(bb.MinZ, sub.MinZ)
+			bb.MaxX, bb.MaxY, bb.MaxZ = max(bb.MaxX, sub.MaxX), max(bb.MaxY, sub.MaxY), max(bb.MaxZ, sub.MaxZ)
+		}
+		return bb, nil
+	}
+	return BoundingBox{pos.X, pos.Y, pos.Z, pos.X, pos.Y, pos.Z}, nil
+}
+
+// shuffledJigsaws 对应 StructurePoolElement.getShuffledJigsawBlocks。
+func (a *jigsawAssembler) shuffledJigsaws(e *JigsawElement, pos Pos3, rot int) []jigsawBlock {
+	switch e.Kind {
+	case JigsawList:
+		return a.shuffledJigsaws(&e.Elements[0], pos, rot)
+	case JigsawFeature:
+		c := &featureJigsaw
+		return []jigsawBlock{{pos, c.Front, c.Top, c}}
+	case JigsawSingle:
+	default:
+		return nil
+	}
+	t := a.d.Templates[e.Location]
+	if t == nil {
+		return nil
+	}
+	list := make([]jigsawBlock, len(t.Jigsaws))
+	for i := range t.Jigsaws {
+		c := &t.Jigsaws[i]
+		p := jigsawOffset(jigsawRotatePos(c.Pos, rot), pos)
+		list[i] = jigsawBlock{p, jigsawRotateDir(c.Front, rot), jigsawRotateDir(c.Top, rot), c}
+	}
+	jigsawShuffle(a.r, list)
+	if a.mc >= MC_1_20_6 {
+		sort.SliceStable(list, func(i, j int) bool {
+			return list[i].c.SelectionPriority > list[j].c.SelectionPriority
+		})
+	}
+	return list
+}
+
+// canAttach 对应 JigsawBlock.canAttach。
+func canAttach(a, b jigsawBlock) bool {
+	return a.front == jigsawOpposite(b.front) && (a.c.Rollable || a.top == b.top) && a.c.Target == b.c.Name
+}
+
+// maxSize 对应 StructureTemplatePool.getMaxSize：池中元素的最大高度。
+func (a *jigsawAssembler) maxSize(name string) int {
+	if v, ok := a.sizes[name]; ok {
+		return v
+	}
+	p, ok := a.pool(name)
+	if !ok {
+		return 0
+	}
+	size := 0
+	for i := range p.Elements {
+		e := &p.Elements[i]
+		if e.Kind == JigsawEmpty {
+			continue
+		}
+		if bb, err := a.d.boundingBox(e, Pos3{}, 0); err == nil {
+			size = max(size, bb.MaxY-bb.MinY+1)
+		}
+	}
+	if a.sizes == nil {
+		a.sizes = map[string]int{}
+	}
+	a.sizes[name] = size
+	return size
+}
+
+// firstFreeHeight 返回 WORLD_SURFACE_WG 高度图上方第一个空气方块的高度。
+func (a *jigsawAssembler) firstFreeHeight(x, z int) int {
+	return a.height(x, z)
+}
+
+// tryPlacingChildren 对应 JigsawPlacement.Placer.tryPlacingChildren。
+func (a *jigsawAssembler) tryPlacingChildren(st jigsawState) error {
+	parent := a.pieces[st.piece]
+	rigid := parent.Element.Rigid
+	bb := parent.BB
+	var inner *jigsawFree
+	y0 := bb.MinY
+
+next:
+	for _, jb := range a.shuffledJigsaws(parent.Element, parent.Pos, parent.Rotation) {
+		step := jigsawStep(jb.front)
+		target := jigsawOffset(jb.pos, step)
+		j := jb.pos.Y - y0
+		k := -1
+		poolName := a.poolName(jb.c.Pool)
+		pool, ok := a.pool(poolName)
+		if !ok || len(pool.Elements) == 0 && poolName != "minecraft:empty" {
+			continue
+		}
+		fallback, ok := a.pool(pool.Fallback)
+		if !ok || len(fallback.Elements) == 0 && pool.Fallback != "minecraft:empty" {
+			continue
+		}
+		free := st.free
+		if bb.Contains(target.X, target.Y, target.Z) {
+			if inner == nil {
+				inner = &jigsawFree{outer: bb}
+			}
+			free = inner
+		}
+
+		var candidates []*JigsawElement
+		if st.depth != a.maxD {
+			candidates = a.shuffledTemplates(pool)
+		}
+		candidates = append(candidates, a.shuffledTemplates(fallback)...)
+
+		for _, cand := range candidates {
+			if cand.Kind == JigsawEmpty {
+				break
+			}
+			rots := []int{0, 1, 2, 3}
+			jigsawShuffle(a.r, rots)
+			for _, rot := range rots {
+				cjs := a.shuffledJigsaws(cand, Pos3{}, rot)
+				bb0, err := a.d.boundingBox(cand, Pos3{}, rot)
+				if err != nil {
+					return err
+				}
+				expand := 0
+				if a.hack && bb0.MaxY-bb0.MinY+1 <= 16 {
+					for _, cj := range cjs {
+						if p := jigsawOffset(cj.pos, jigsawStep(cj.front)); !bb0.Contains(p.X, p.Y, p.Z) {
+							continue
+						}
+						name := a.poolName(cj.c.Pool)
+						size := a.maxSize(name)
+						if p, ok := a.pool(name); ok {
+							size = max(size, a.maxSize(p.Fallback))
+						}
+						expand = max(expand, size)
+					}
+				}
+
+				for _, cj := range cjs {
+					if !canAttach(jb, cj) {
+						continue
+					}
+					off := Pos3{target.X - cj.pos.X, target.Y - cj.pos.Y, target.Z - cj.pos.Z}
+					cbb, err := a.d.boundingBox(cand, off, rot)
+					if err != nil {
+						return err
+					}
+					k1 := cj.pos.Y
+					l1 := j - k1 + step.Y
+					var i2 int
+					if rigid && cand.Rigid {
+						i2 = y0 + l1
+					} else {
+						if k == -1 {
+							k = a.firstFreeHeight(jb.pos.X, jb.pos.Z)
+						}
+						i2 = k - k1
+					}
+					dy := i2 - cbb.MinY
+					cbb = bbMoved(cbb, 0, dy, 0)
+					off.Y += dy
+					if expand > 0 {
+						cbb.MaxY = max(cbb.MaxY, cbb.MinY+max(expand+1, cbb.MaxY-cbb.MinY))
+					}
+					if !free.fits(cbb) {
+						continue
+					}
+					free.holes = append(free.holes, cbb)
+
+					typ := JigsawRigid
+					if !cand.Rigid {
+						typ = JigsawTerrainMatching
+					}
+					a.pieces = append(a.pieces, JigsawPiece{
+						Piece:   Piece{Name: cand.name(), Type: typ, Pos: off, BB: cbb, Rotation: rot, Depth: st.depth + 1},
+						Element: cand,
+					})
+					if st.depth+1 <= a.maxD {
+						priority := 0
+						if a.mc >= MC_1_20_6 {
+							priority = jb.c.PlacementPriority
+						}
+						a.queue.add(jigsawState{len(a.pieces) - 1, free, st.depth + 1}, priority)
+					}
+					continue next
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// name 返回元素的（第一个）模板名。
+func (e *JigsawElement) name() string {
+	switch e.Kind {
+	case JigsawList:
+		return e.Elements[0].name()
+	case JigsawFeature:
+		return "feature"
+	}
+	return e.Location
+}
+
+// buildAliases 对应 PoolAliasLookup.create：用世界种子和结构起点的位置随机数解析别名。
+func buildAliases(bindings []JigsawAlias, seed uint64, pos Pos3) map[string]string {
+	if len(bindings) == 0 {
+		return nil
+	}
+	fork := NewRng(seed).NextLong()
+	r := NewRng(uint64(positionalSeed(pos.X, pos.Y, pos.Z) ^ fork))
+	out := map[string]string{}
+	var resolve func(b JigsawAlias)
+	resolve = func(b JigsawAlias) {
+		switch b.Kind {
+		case "direct":
+			if len(b.Targets) > 0 {
+				out[b.Alias] = b.Targets[0].Pool
+			}
+		case "random":
+			total := 0
+			for _, t := range b.Targets {
+				total += t.Weight
+			}
+			if total == 0 {
+				return
+			}
+			k := r.NextInt(total)
+			for _, t := range b.Targets {
+				if k -= t.Weight; k < 0 {
+					out[b.Alias] = t.Pool
+					return
+				}
+			}
+		case "random_group":
+			total := 0
+			for _, g := range b.Groups {
+				total += g.Weight
+			}
+			if total == 0 {
+				return
+			}
+			k := r.NextInt(total)
+			for _, g := range b.Groups {
+				if k -= g.Weight; k < 0 {
+					for _, sub := range g.Bindings {
+						resolve(sub)
+					}
+					return
+				}
+			}
+		}
+	}
+	for _, b := range bindings {
+		resolve(b)
+	}
+	return out
+}
+
+// positionalSeed 对应 Mth.getSeed(x, y, z)。
+func positionalSeed(x, y, z int) int64 {
+	l := int64(int32(x)*3129871) ^ int64(z)*116129781 ^ int64(y)
+	l = l*l*42317861 + l*11
+	return l >> 16
+}
+
+// defaultJigsawStructure 返回 1.19 以前（结构定义尚未数据驱动）的拼图结构。
+func defaultJigsawStructure(mc int, name string) *JigsawStructure {
+	zero, y33 := 0, 33
+	switch name {
+	case "minecraft:village_plains", "minecraft:village_desert", "minecraft:village_savanna",
+		"minecraft:village_snowy", "minecraft:village_taiga":
+		pool := "minecraft:village/" + name[len("minecraft:village_"):] + "/town_centers"
+		return &JigsawStructure{StartPool: pool, Size: 6, StartHeight: JigsawHeight{JigsawAnchor{Absolute: &zero}, JigsawAnchor{Absolute: &zero}, false},
+			ProjectToHeightmap: true, ExpansionHack: true}
+	case "minecraft:pillager_outpost":
+		return &JigsawStructure{StartPool: "minecraft:pillager_outpost/base_plates", Size: 7, StartHeight: JigsawHeight{JigsawAnchor{Absolute: &zero}, JigsawAnchor{Absolute: &zero}, false},
+			ProjectToHeightmap: true, ExpansionHack: true}
+	case "minecraft:bastion_remnant":
+		return &JigsawStructure{StartPool: "minecraft:bastion/starts", Size: 6, StartHeight: JigsawHeight{JigsawAnchor{Absolute: &y33}, JigsawAnchor{Absolute: &y33}, false}}
+	}
+	return nil
+}
+
+// jigsawStructureName 返回结构类型在生物群系 biome 中对应的拼图结构名。
+func jigsawStructureName(mc int, st StructureType, biome Biome) (string, error) {
+	switch st {
+	case Village:
+		if mc < MC_1_14 {
+			return "", fmt.Errorf("villages are not jigsaw structures in version %v", mc)
+		}
+		switch style, _ := villageStyle(mc, biome); style {
+		case Plains:
+			return "minecraft:village_plains", nil
+		case Desert:
+			return "minecraft:village_desert", nil
+		case Savanna:
+			return "minecraft:village_savanna", nil
+		case Taiga:
+			return "minecraft:village_taiga", nil
+		case SnowyTundra:
+			return "minecraft:village_snowy", nil
+		}
+		return "", nil
+	case Outpost:
+		return "minecraft:pillager_outpost", nil
+	case Bastion:
+		return "minecraft:bastion_remnant", nil
+	case AncientCity:
+		return "minecraft:ancient_city", nil
+	case TrailRuins:
+		return "minecraft:trail_ruins", nil
+	case TrialChambers:
+		return "minecraft:trial_chambers", nil
+	}
+	return "", fmt.Errorf("structure %v is not a jigsaw structure", st)
+}
+
+// GetJigsawPieces 组装拼图结构（村庄、前哨站、堡垒遗迹、古城、古迹废墟、试炼密室）在 pos
+// （GetStructurePos 返回的生成尝试位置）处的部件，biome 为 pos 处的生物群系，用于选择村庄风格；
+// 该生物群系下不生成时返回 nil, nil。结果的第一个部件为起始部件。
+//
+// 组装过程与原版 JigsawPlacement 一致，使用结构 RNG 和 data 中的模板池元数据；
+// data 为 nil 时使用 DefaultJigsawData 嵌入的数据，LoadJigsawData 读取的数据可以替代它。
+// 贴合地形的部件和投影到高度图的起点需要 WORLD_SURFACE_WG 高度图上方第一个空气方块的高度，
+// 由 height 给出；height 为 nil 时按平坦地形取 64。地形与实际不符时，贴合地形部件的高度以及
+// 与其相关的碰撞判定可能与游戏不同，刚性部件之间的相对位置不受影响。
+func (f *Finder) GetJigsawPieces(data *JigsawData, st StructureType, seed uint64, pos Pos, biome Biome, height func(x, z int) int) ([]JigsawPiece, error) {
+	mc := f.Version
+	name, err := jigsawStructureName(mc, st, biome)
+	if err != nil || name == "" {
+		return nil, err
+	}
+	if data == nil {
+		if data, err = DefaultJigsawData(mc); err != nil {
+			return nil, err
+		}
+	}
+	def := data.Structures[name]
+	if def == nil && mc < MC_1_19_2 {
+		def = defaultJigsawStructure(mc, name)
+	}
+	if def == nil {
+		return nil, fmt.Errorf("jigsaw data has no structure %q", name)
+	}
+	if height == nil {
+		height = func(int, int) int { return 64 }
+	}
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return nil, err
+	}
+
+	cx, cz := pos.X>>4, pos.Z>>4
+	r := &Rng{seed: f.ChunkGenerateRnd(seed, cx, cz)}
+	minY, maxY := dimHeightRange(mc, config.Dim)
+	y := def.StartHeight.Min.resolve(minY, maxY)
+	if hi := def.StartHeight.Max.resolve(minY, maxY); def.StartHeight.Uniform && hi >= y {
+		y += r.NextInt(hi - y + 1)
+	}
+	start := Pos3{cx << 4, y, cz << 4}
+
+	a := &jigsawAssembler{
+		mc:      mc,
+		d:       data,
+		r:       r,
+		maxD:    def.Size,
+		hack:    def.ExpansionHack,
+		height:  height,
+		aliases: buildAliases(def.Aliases, seed, start),
+	}
+	rot := r.NextInt(4)
+	pool, ok := a.pool(a.poolName(def.StartPool))
+	if !ok {
+		return nil, fmt.Errorf("jigsaw data has no template pool %q", def.StartPool)
+	}
+	templates := pool.templates()
+	if len(templates) == 0 {
+		return nil, nil
+	}
+	el := templates[r.NextInt(len(templates))]
+	if el.Kind == JigsawEmpty {
+		return nil, nil
+	}
+
+	// 有 start_jigsaw_name 时把该拼图方块对齐到起点
+	var vec Pos3
+	if def.StartJigsawName != "" {
+		found := false
+		for _, jb := range a.shuffledJigsaws(el, start, rot) {
+			if jb.c.Name == def.StartJigsawName {
+				vec = Pos3{jb.pos.X - start.X, jb.pos.Y - start.Y, jb.pos.Z - start.Z}
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, nil
+		}
+	}
+	base := Pos3{start.X - vec.X, start.Y - vec.Y, start.Z - vec.Z}
+	bb, err := data.boundingBox(el, base, rot)
+	if err != nil {
+		return nil, err
+	}
+	ci, cj := (bb.MaxX+bb.MinX)/2, (bb.MaxZ+bb.MinZ)/2
+	k := base.Y
+	if def.ProjectToHeightmap {
+		k = start.Y + height(ci, cj)
+	}
+	// 起始部件的 groundLevelDelta 为 1
+	dy := k - (bb.MinY + 1)
+	bb = bbMoved(bb, 0, dy, 0)
+	base.Y += dy
+	typ := JigsawRigid
+	if !el.Rigid {
+		typ = JigsawTerrainMatching
+	}
+	a.pieces = append(a.pieces, JigsawPiece{
+		Piece:   Piece{Name: el.name(), Type: typ, Pos: base, BB: bb, Rotation: rot},
+		Element: el,
+	})
+	if def.Size <= 0 {
+		return a.pieces, nil
+	}
+
+	cy := k + vec.Y
+	d := def.MaxDistance
+	if d == 0 {
+		d = 80
+	}
+	free := &jigsawFree{
+		outer: BoundingBox{ci - d, cy - d, cj - d, ci + d, cy + d, cj + d},
+		holes: []BoundingBox{bb},
+	}
+	a.queue.add(jigsawState{0, free, 0}, 0)
+	for {
+		s, ok := a.queue.next()
+		if !ok {
+			break
+		}
+		if err := a.tryPlacingChildren(s); err != nil {
+			return nil, err
+		}
+	}
+	return a.pieces, nil
+}
+
+// GetJigsawPieces 组装拼图结构在 pos 处的部件，使用生成器的种子和 pos 处的生物群系，参数含义同 Finder.GetJigsawPieces。
+func (gen *Generator) GetJigsawPieces(data *JigsawData, st StructureType, pos Pos, height func(x, z int) int) ([]JigsawPiece, error) {
+	biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
+	return NewFinder(gen.Version).GetJigsawPieces(data, st, gen.Seed, pos, biome, height)
+}
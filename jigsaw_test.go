@@ -0,0 +1,149 @@
+package gobiomes
+
+import (
+	"io/fs"
+	"sync"
+	"testing"
+	"testing/fstest"
+)
+
+func TestGetJigsawPiecesVillage(t *testing.T) {
+	tests := []struct {
+		mc     int
+		seed   uint64
+		biome  Biome
+		pos    Pos
+		pieces int
+		start  string
+		origin Pos3
+		rot    int
+		second string
+		bell   Pos3
+	}{
+		{MC_1_16_5, 1, Plains, Pos{80, 64}, 99, "minecraft:village/plains/town_centers/plains_fountain_01", Pos3{80, 63, 64}, 1, "minecraft:village/plains/villagers/unemployed", Pos3{78, 65, 68}},
+		{MC_1_17_1, 7, Desert, Pos{0, 192}, 109, "minecraft:village/desert/town_centers/desert_meeting_point_1", Pos3{0, 63, 192}, 3, "minecraft:village/common/animals/cat_british", Pos3{4, 65, 182}},
+		{MC_1_18_2, 42, Plains, Pos{144, 176}, 118, "minecraft:village/plains/town_centers/plains_meeting_point_2", Pos3{144, 63, 176}, 1, "minecraft:village/plains/villagers/unemployed", Pos3{134, 65, 181}},
+		{MC_1_21_1, 1, Plains, Pos{240, 128}, 120, "minecraft:village/plains/town_centers/plains_meeting_point_1", Pos3{240, 63, 128}, 2, "minecraft:village/plains/streets/crossroad_02", Pos3{237, 65, 121}},
+		{MC_1_21_WD, 42, Plains, Pos{144, 176}, 77, "minecraft:village/plains/town_centers/plains_meeting_point_2", Pos3{144, 63, 176}, 1, "minecraft:village/plains/villagers/unemployed", Pos3{134, 65, 181}},
+		{MC_1_21_WD, 7, Desert, Pos{224, 352}, 129, "minecraft:village/desert/town_centers/desert_meeting_point_2", Pos3{224, 63, 352}, 0, "minecraft:village/desert/streets/straight_02", Pos3{227, 64, 353}},
+	}
+	for _, tt := range tests {
+		f := NewFinder(tt.mc)
+		d, err := DefaultJigsawData(tt.mc)
+		if err != nil {
+			t.Fatalf("mc %d: %v", tt.mc, err)
+		}
+		pos, err := f.GetStructurePos(Village, tt.seed, 0, 0)
+		if err != nil || pos == nil || *pos != tt.pos {
+			t.Errorf("mc %d seed %d: village at %v (%v), want %v", tt.mc, tt.seed, pos, err, tt.pos)
+			continue
+		}
+		ps, err := f.GetJigsawPieces(nil, Village, tt.seed, *pos, tt.biome, nil)
+		if err != nil {
+			t.Fatalf("mc %d seed %d: %v", tt.mc, tt.seed, err)
+		}
+		if len(ps) != tt.pieces || ps[0].Name != tt.start || ps[0].Pos != tt.origin || ps[0].Rotation != tt.rot || ps[1].Name != tt.second {
+			t.Errorf("mc %d seed %d: %d pieces, start %s at %v rotation %d, then %s; want %d, %s at %v rotation %d, then %s",
+				tt.mc, tt.seed, len(ps), ps[0].Name, ps[0].Pos, ps[0].Rotation, ps[1].Name, tt.pieces, tt.start, tt.origin, tt.rot, tt.second)
+			continue
+		}
+		var bells []Pos3
+		for _, m := range ps[0].Markers(d) {
+			if m.ID == "minecraft:bell" {
+				bells = append(bells, m.Pos)
+			}
+		}
+		if len(bells) != 1 || bells[0] != tt.bell {
+			t.Errorf("mc %d seed %d: bells %v, want %v", tt.mc, tt.seed, bells, tt.bell)
+		} else if !ps[0].BB.Contains(tt.bell.X, tt.bell.Y, tt.bell.Z) {
+			t.Errorf("mc %d seed %d: bell %v outside start piece %v", tt.mc, tt.seed, tt.bell, ps[0].BB)
+		}
+	}
+}
+
+func TestGetJigsawPiecesTrialChambers(t *testing.T) {
+	f := NewFinder(MC_1_21_WD)
+	d, err := DefaultJigsawData(MC_1_21_WD)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pos, err := f.GetStructurePos(TrialChambers, 1, 0, 0)
+	if err != nil || pos == nil || *pos != (Pos{288, 224}) {
+		t.Fatalf("trial chambers at %v (%v), want {288 224}", pos, err)
+	}
+	ps, err := f.GetJigsawPieces(nil, TrialChambers, 1, *pos, Plains, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(ps) != 229 || ps[0].Name != "minecraft:trial_chambers/corridor/end_2" || ps[0].Pos != (Pos3{288, -21, 224}) || ps[0].Rotation != 2 {
+		t.Fatalf("%d pieces, start %s at %v rotation %d", len(ps), ps[0].Name, ps[0].Pos, ps[0].Rotation)
+	}
+	bb := JigsawBoundingBox(ps)
+	vaults := 0
+	for i := range ps {
+		for _, m := range ps[i].Markers(d) {
+			if m.ID != "minecraft:vault" {
+				continue
+			}
+			vaults++
+			if !bb.Contains(m.Pos.X, m.Pos.Y, m.Pos.Z) {
+				t.Errorf("vault %v outside %v", m.Pos, bb)
+			}
+		}
+	}
+	if vaults != 29 {
+		t.Errorf("%d vaults, want 29", vaults)
+	}
+
+	// 1.21.1 的数据不含试炼密室
+	if _, err := NewFinder(MC_1_21_1).GetJigsawPieces(nil, TrialChambers, 1, *pos, Plains, nil); err == nil {
+		t.Errorf("1.21.1 trial chambers: want an error for the missing structure")
+	}
+}
+
+func TestGetJigsawPiecesConcurrent(t *testing.T) {
+	f := NewFinder(MC_1_21_WD)
+	pos := Pos{144, 176}
+	want, err := f.GetJigsawPieces(nil, Village, 42, pos, Plains, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 使用新解析的数据，让各 goroutine 同时从空的状态开始组装
+	raw, err := fs.ReadFile(jigsawBundled, "jigsawdata/1.21.4.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	d, err := ParseJigsawData(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			ps, err := f.GetJigsawPieces(d, Village, 42, pos, Plains, nil)
+			if err != nil || len(ps) != len(want) {
+				t.Errorf("concurrent assembly: %d pieces (%v), want %d", len(ps), err, len(want))
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestLoadJigsawDataMissingTemplate(t *testing.T) {
+	fsys := fstest.MapFS{
+		"data/minecraft/worldgen/template_pool/test.json": {Data: []byte(`{"fallback":"minecraft:empty","elements":[
+			{"weight":1,"element":{"element_type":"minecraft:single_pool_element","location":"minecraft:test/missing","projection":"rigid","processors":"minecraft:empty"}}]}`)},
+	}
+	d, err := LoadJigsawData(fsys)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Missing) != 1 || d.Missing[0] != "minecraft:test/missing" {
+		t.Errorf("missing %v, want [minecraft:test/missing]", d.Missing)
+	}
+	if tp := d.Templates["minecraft:test/missing"]; tp == nil || tp.Size != (Pos3{}) {
+		t.Errorf("template %v, want an empty template", tp)
+	}
+}
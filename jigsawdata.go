@@ -0,0 +1,602 @@
+package gobiomes
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// 拼图方块朝向中的竖直方向，水平方向沿用 dirNorth ~ dirWest。
+const (
+	dirUp   = 4
+	dirDown = 5
+)
+
+// JigsawConnector 是模板中的一个拼图方块，坐标为模板局部坐标（未旋转）。
+type JigsawConnector struct {
+	Pos               Pos3
+	Front             int    // 拼图方块正面朝向：dirNorth, dirEast, dirSouth, dirWest, 4 (上), 5 (下)
+	Top               int    // 拼图方块顶面朝向
+	Name              string // 本连接点的名称
+	Target            string // 可连接的连接点名称
+	Pool              string // 子部件的模板池
+	Rollable          bool   // joint 为 rollable（连接时不要求顶面朝向一致）
+	SelectionPriority int    `json:",omitempty"`
+	PlacementPriority int    `json:",omitempty"`
+}
+
+// JigsawMarker 是模板中值得关注的方块或实体，例如钟、工作站方块、铁傀儡、试炼刷怪笼和宝库。
+type JigsawMarker struct {
+	ID     string // 方块或实体 ID，例如 "minecraft:bell"、"minecraft:iron_golem"
+	Pos    Pos3
+	Entity bool `json:",omitempty"`
+}
+
+// JigsawTemplate 是结构模板的元数据：尺寸、拼图方块和标记，不包含方块数据。
+type JigsawTemplate struct {
+	Size    Pos3
+	Jigsaws []JigsawConnector `json:",omitempty"` // 按原版 StructureTemplate 的顺序（y, x, z）排列
+	Markers []JigsawMarker    `json:",omitempty"`
+}
+
+// 模板池元素类型，对应 StructurePoolElement 的各子类。
+const (
+	JigsawSingle  = "single"  // single_pool_element / legacy_single_pool_element
+	JigsawList    = "list"    // list_pool_element
+	JigsawFeature = "feature" // feature_pool_element
+	JigsawEmpty   = "empty"   // empty_pool_element
+)
+
+// JigsawElement 对应 StructurePoolElement。
+type JigsawElement struct {
+	Kind     string
+	Location string          `json:",omitempty"` // single 元素的模板名
+	Elements []JigsawElement `json:",omitempty"` // list 元素的子元素
+	Rigid    bool            `json:",omitempty"` // projection 为 rigid，否则为 terrain_matching
+	Weight   int             `json:",omitempty"` // 在模板池中的权重
+}
+
+// JigsawPool 对应 StructureTemplatePool。
+type JigsawPool struct {
+	Fallback string
+	Elements []JigsawElement
+}
+
+// JigsawAnchor 对应 VerticalAnchor，三者只有一个非空。
+type JigsawAnchor struct {
+	Absolute    *int `json:"absolute,omitempty"`
+	AboveBottom *int `json:"above_bottom,omitempty"`
+	BelowTop    *int `json:"below_top,omitempty"`
+}
+
+// resolve 返回锚点在高度范围 [minY, maxY] 中对应的 Y。
+func (a JigsawAnchor) resolve(minY, maxY int) int {
+	switch {
+	case a.AboveBottom != nil:
+		return minY + *a.AboveBottom
+	case a.BelowTop != nil:
+		return maxY - *a.BelowTop
+	case a.Absolute != nil:
+		return *a.Absolute
+	}
+	return 0
+}
+
+// JigsawHeight 对应拼图结构的 start_height：constant 或 uniform 高度。
+type JigsawHeight struct {
+	Min, Max JigsawAnchor
+	Uniform  bool `json:",omitempty"` // uniform 高度会消耗一次 nextInt
+}
+
+// JigsawAlias 对应 pool_aliases 中的一项（direct、random 或 random_group）。
+type JigsawAlias struct {
+	Kind    string              // "direct", "random", "random_group"
+	Alias   string              `json:",omitempty"`
+	Targets []JigsawAliasTarget `json:",omitempty"`
+	Groups  []JigsawAliasGroup  `json:",omitempty"`
+}
+
+// JigsawAliasTarget 是 direct/random 别名的一个目标模板池。
+type JigsawAliasTarget struct {
+	Pool   string
+	Weight int
+}
+
+// JigsawAliasGroup 是 random_group 别名的一组绑定。
+type JigsawAliasGroup struct {
+	Weight   int
+	Bindings []JigsawAlias
+}
+
+// JigsawStructure 对应 worldgen/structure 中 type 为 minecraft:jigsaw 的结构定义。
+type JigsawStructure struct {
+	StartPool          string
+	StartJigsawName    string `json:",omitempty"`
+	Size               int    // 最大深度
+	StartHeight        JigsawHeight
+	ProjectToHeightmap bool          `json:",omitempty"` // project_start_to_heightmap 为 WORLD_SURFACE_WG
+	ExpansionHack      bool          `json:",omitempty"`
+	MaxDistance        int           `json:",omitempty"` // max_distance_from_center，0 表示 80
+	Aliases            []JigsawAlias `json:",omitempty"`
+}
+
+// JigsawData 是拼图结构组装所需的元数据：模板池、模板尺寸与拼图方块，以及（1.19+ 的）结构定义。
+// 键为带命名空间的资源名，例如 "minecraft:village/plains/town_centers"。
+//
+// DefaultJigsawData 提供随包嵌入的部分版本的数据；其它版本或数据包可以用 LoadJigsawData 从游戏 jar
+// （archive/zip.Reader 实现了 fs.FS）或数据包中提取，用 json.Marshal 保存后通过 ParseJigsawData 重新读取，
+// cmd/jigsawdata 按这种方式生成嵌入的文件。
+type JigsawData struct {
+	Templates  map[string]*JigsawTemplate
+	Pools      map[string]*JigsawPool
+	Structures map[string]*JigsawStructure `json:",omitempty"`
+	Missing    []string                    `json:",omitempty"` // 模板池引用但找不到文件的模板，按原版 getOrCreate 视为空模板
+}
+
+// jigsawKey 为资源名补全默认命名空间。
+func jigsawKey(s string) string {
+	if s == "" || strings.Contains(s, ":") {
+		return s
+	}
+	return "minecraft:" + s
+}
+
+// jigsawBundled 是用 cmd/jigsawdata 提取到 jigsawdata 目录的各版本拼图结构元数据。
+//
+//go:embed jigsawdata/*.json
+var jigsawBundled embed.FS
+
+// jigsawDataVersions 是各版本使用的嵌入数据文件（jigsawdata/<版本>.json）。
+// 1.16.5 与 1.18.2 的模板池相同，1.17.1 和 1.18.2 共用 1.16.5 的文件。
+// 1.21.1 的文件不含试炼密室：其模板在之后的版本中改动过，无法得到 1.21.1 的原始模板。
+var jigsawDataVersions = map[int]string{
+	MC_1_16_5:  "1.16.5",
+	MC_1_17_1:  "1.16.5",
+	MC_1_18_2:  "1.16.5",
+	MC_1_21_1:  "1.21.1",
+	MC_1_21_WD: "1.21.4",
+}
+
+var (
+	defaultJigsawMu   sync.Mutex
+	defaultJigsawData = map[string]*JigsawData{}
+)
+
+// DefaultJigsawData 返回随包嵌入的版本 mc 的拼图结构元数据。
+// 目前嵌入 1.16.5、1.17.1、1.18.2、1.21.1（不含试炼密室）和 1.21.4 的数据，其它版本返回错误，
+// 此时可以用 LoadJigsawData 或 ParseJigsawData 提供数据。
+// 模板池和结构定义取自对应版本，模板尺寸、拼图方块和标记取自 26.1.1 的模板文件。
+func DefaultJigsawData(mc int) (*JigsawData, error) {
+	name, ok := jigsawDataVersions[mc]
+	if !ok {
+		return nil, fmt.Errorf("jigsaw data: no data file for version %v", mc)
+	}
+	defaultJigsawMu.Lock()
+	defer defaultJigsawMu.Unlock()
+	if d := defaultJigsawData[name]; d != nil {
+		return d, nil
+	}
+	data, err := fs.ReadFile(jigsawBundled, "jigsawdata/"+name+".json")
+	if err != nil {
+		return nil, fmt.Errorf("jigsaw data: %w", err)
+	}
+	d, err := ParseJigsawData(data)
+	if err != nil {
+		return nil, err
+	}
+	defaultJigsawData[name] = d
+	return d, nil
+}
+
+// ParseJigsawData 解析 json.Marshal(JigsawData) 得到的数据。
+func ParseJigsawData(data []byte) (*JigsawData, error) {
+	d := &JigsawData{}
+	if err := json.Unmarshal(data, d); err != nil {
+		return nil, fmt.Errorf("jigsaw data: %w", err)
+	}
+	if d.Templates == nil || d.Pools == nil {
+		return nil, fmt.Errorf("jigsaw data: missing templates or pools")
+	}
+	return d, nil
+}
+
+// jigsawMarkerBlocks 是提取为标记的方块：钟、村民工作站方块、刷怪笼、试炼刷怪笼和宝库。
+var jigsawMarkerBlocks = map[string]bool{
+	"minecraft:bell":              true,
+	"minecraft:barrel":            true,
+	"minecraft:blast_furnace":     true,
+	"minecraft:brewing_stand":     true,
+	"minecraft:cartography_table": true,
+	"minecraft:cauldron":          true,
+	"minecraft:water_cauldron":    true,
+	"minecraft:composter":         true,
+	"minecraft:fletching_table":   true,
+	"minecraft:grindstone":        true,
+	"minecraft:lectern":           true,
+	"minecraft:loom":              true,
+	"minecraft:smithing_table":    true,
+	"minecraft:smoker":            true,
+	"minecraft:stonecutter":       true,
+	"minecraft:spawner":           true,
+	"minecraft:trial_spawner":     true,
+	"minecraft:vault":             true,
+}
+
+// LoadJigsawData 从游戏 jar、数据包或数据生成器的输出中读取拼图结构元数据：
+// data/<ns>/worldgen/template_pool 下的模板池、其引用的 data/<ns>/structure(s)/*.nbt 模板，
+// 以及 data/<ns>/worldgen/structure 下的拼图结构定义。后面的 fsys 中的同名资源覆盖前面的。
+//
+// 1.19 以前的 jar 不包含 worldgen JSON：1.16.2 ~ 1.18.2 可以同时传入 jar 和数据生成器（--reports）
+// 输出的 generated 目录，从其中的 reports/worldgen/<ns>/worldgen/template_pool 读取模板池。
+// 1.14 ~ 1.16.1 的模板池写在游戏代码中，无法从文件读取。
+// 找不到的模板与原版一样当作空模板，名称记录在 Missing 中。
+func LoadJigsawData(fsys ...fs.FS) (*JigsawData, error) {
+	d := &JigsawData{
+		Templates:  map[string]*JigsawTemplate{},
+		Pools:      map[string]*JigsawPool{},
+		Structures: map[string]*JigsawStructure{},
+	}
+	for _, fsys := range fsys {
+		for _, pattern := range []string{"data/*", "reports/worldgen/*"} {
+			namespaces, err := fs.Glob(fsys, pattern)
+			if err != nil {
+				return nil, err
+			}
+			for _, dir := range namespaces {
+				ns := path.Base(dir)
+				if err := d.walkJSON(fsys, path.Join(dir, "worldgen/template_pool"), ns, d.addPool); err != nil {
+					return nil, err
+				}
+				if err := d.walkJSON(fsys, path.Join(dir, "worldgen/structure"), ns, d.addStructure); err != nil {
+					return nil, err
+				}
+			}
+		}
+	}
+	if len(d.Pools) == 0 {
+		return nil, fmt.Errorf("jigsaw data: no template pools found")
+	}
+
+	var locations []string
+	for _, p := range d.Pools {
+		for _, e := range p.Elements {
+			locations = e.appendLocations(locations)
+		}
+	}
+	for _, loc := range locations {
+		if d.Templates[loc] != nil {
+			continue
+		}
+		var t *JigsawTemplate
+		var err error
+		for i := len(fsys) - 1; i >= 0 && t == nil; i-- {
+			if t, err = loadJigsawTemplate(fsys[i], loc); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, err
+			}
+		}
+		if t == nil {
+			// 原版 StructureTemplateManager.getOrCreate 对不存在的模板返回空模板（尺寸为 0）
+			t = &JigsawTemplate{}
+			d.Missing = append(d.Missing, loc)
+		}
+		d.Templates[loc] = t
+	}
+	sort.Strings(d.Missing)
+	return d, nil
+}
+
+// walkJSON 对 root 下的每个 JSON 文件调用 add，资源名为 ns 加上相对路径。root 不存在时忽略。
+func (d *JigsawData) walkJSON(fsys fs.FS, root, ns string, add func(name string, data []byte) error) error {
+	if _, err := fs.Stat(fsys, root); err != nil {
+		return nil
+	}
+	return fs.WalkDir(fsys, root, func(p string, e fs.DirEntry, err error) error {
+		if err != nil || e.IsDir() || path.Ext(p) != ".json" {
+			return err
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		name := ns + ":" + strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".json")
+		return add(name, data)
+	})
+}
+
+// appendLocations 把元素引用的模板名追加到 out。
+func (e *JigsawElement) appendLocations(out []string) []string {
+	if e.Kind == JigsawSingle {
+		out = append(out, e.Location)
+	}
+	for i := range e.Elements {
+		out = e.Elements[i].appendLocations(out)
+	}
+	return out
+}
+
+type poolElementJSON struct {
+	ElementType string            `json:"element_type"`
+	Location    json.RawMessage   `json:"location"`
+	Elements    []poolElementJSON `json:"elements"`
+	Projection  string            `json:"projection"`
+}
+
+func (e poolElementJSON) convert(name string) (JigsawElement, error) {
+	out := JigsawElement{Rigid: e.Projection != "terrain_matching"}
+	switch strings.TrimPrefix(e.ElementType, "minecraft:") {
+	case "single_pool_element", "legacy_single_pool_element":
+		out.Kind = JigsawSingle
+		if err := json.Unmarshal(e.Location, &out.Location); err != nil {
+			return out, fmt.Errorf("template pool %q: unsupported location: %w", name, err)
+		}
+		out.Location = jigsawKey(out.Location)
+	case "list_pool_element":
+		out.Kind = JigsawList
+		for _, sub := range e.Elements {
+			s, err := sub.convert(name)
+			if err != nil {
+				return out, err
+			}
+			// 列表元素的投影方式会覆盖子元素
+			s.Rigid = out.Rigid
+			out.Elements = append(out.Elements, s)
+		}
+		if len(out.Elements) == 0 {
+			return out, fmt.Errorf("template pool %q: empty list element", name)
+		}
+	case "feature_pool_element":
+		out.Kind = JigsawFeature
+	case "empty_pool_element":
+		out.Kind = JigsawEmpty
+	default:
+		return out, fmt.Errorf("template pool %q: unknown element type %q", name, e.ElementType)
+	}
+	return out, nil
+}
+
+func (d *JigsawData) addPool(name string, data []byte) error {
+	var js struct {
+		Fallback string `json:"fallback"`
+		Elements []struct {
+			Weight  int             `json:"weight"`
+			Element poolElementJSON `json:"element"`
+		} `json:"elements"`
+	}
+	if err := json.Unmarshal(data, &js); err != nil {
+		return fmt.Errorf("template pool %q: %w", name, err)
+	}
+	p := &JigsawPool{Fallback: jigsawKey(js.Fallback)}
+	for _, e := range js.Elements {
+		el, err := e.Element.convert(name)
+		if err != nil {
+			return err
+		}
+		el.Weight = e.Weight
+		p.Elements = append(p.Elements, el)
+	}
+	d.Pools[name] = p
+	return nil
+}
+
+type aliasJSON struct {
+	Type    string `json:"type"`
+	Alias   string `json:"alias"`
+	Target  string `json:"target"`
+	Targets []struct {
+		Data   string `json:"data"`
+		Weight int    `json:"weight"`
+	} `json:"targets"`
+	Groups []struct {
+		Data   []aliasJSON `json:"data"`
+		Weight int         `json:"weight"`
+	} `json:"groups"`
+}
+
+func (a aliasJSON) convert() JigsawAlias {
+	out := JigsawAlias{Kind: strings.TrimPrefix(a.Type, "minecraft:"), Alias: jigsawKey(a.Alias)}
+	if a.Target != "" {
+		out.Targets = append(out.Targets, JigsawAliasTarget{jigsawKey(a.Target), 1})
+	}
+	for _, t := range a.Targets {
+		out.Targets = append(out.Targets, JigsawAliasTarget{jigsawKey(t.Data), t.Weight})
+	}
+	for _, g := range a.Groups {
+		group := JigsawAliasGroup{Weight: g.Weight}
+		for _, b := range g.Data {
+			group.Bindings = append(group.Bindings, b.convert())
+		}
+		out.Groups = append(out.Groups, group)
+	}
+	return out
+}
+
+// parseJigsawHeight 解析 HeightProvider：单独的 VerticalAnchor、constant 或 uniform。
+func parseJigsawHeight(data json.RawMessage) (JigsawHeight, error) {
+	var js struct {
+		Type  string       `json:"type"`
+		Value JigsawAnchor `json:"value"`
+		Min   JigsawAnchor `json:"min_inclusive"`
+		Max   JigsawAnchor `json:"max_inclusive"`
+		JigsawAnchor
+	}
+	if len(data) == 0 {
+		return JigsawHeight{}, nil
+	}
+	if err := json.Unmarshal(data, &js); err != nil {
+		return JigsawHeight{}, err
+	}
+	switch strings.TrimPrefix(js.Type, "minecraft:") {
+	case "":
+		return JigsawHeight{Min: js.JigsawAnchor, Max: js.JigsawAnchor}, nil
+	case "constant":
+		return JigsawHeight{Min: js.Value, Max: js.Value}, nil
+	case "uniform":
+		return JigsawHeight{Min: js.Min, Max: js.Max, Uniform: true}, nil
+	}
+	return JigsawHeight{}, fmt.Errorf("unsupported height provider %q", js.Type)
+}
+
+func (d *JigsawData) addStructure(name string, data []byte) error {
+	var js struct {
+		Type            string          `json:"type"`
+		StartPool       string          `json:"start_pool"`
+		StartJigsawName string          `json:"start_jigsaw_name"`
+		Size            int             `json:"size"`
+		StartHeight     json.RawMessage `json:"start_height"`
+		Project         string          `json:"project_start_to_heightmap"`
+		ExpansionHack   bool            `json:"use_expansion_hack"`
+		MaxDistance     int             `json:"max_distance_from_center"`
+		Aliases         []aliasJSON     `json:"pool_aliases"`
+	}
+	if err := json.Unmarshal(data, &js); err != nil {
+		return fmt.Errorf("structure %q: %w", name, err)
+	}
+	if strings.TrimPrefix(js.Type, "minecraft:") != "jigsaw" {
+		return nil
+	}
+	h, err := parseJigsawHeight(js.StartHeight)
+	if err != nil {
+		return fmt.Errorf("structure %q: %w", name, err)
+	}
+	s := &JigsawStructure{
+		StartPool:          jigsawKey(js.StartPool),
+		StartJigsawName:    jigsawKey(js.StartJigsawName),
+		Size:               js.Size,
+		StartHeight:        h,
+		ProjectToHeightmap: js.Project != "",
+		ExpansionHack:      js.ExpansionHack,
+		MaxDistance:        js.MaxDistance,
+	}
+	for _, a := range js.Aliases {
+		s.Aliases = append(s.Aliases, a.convert())
+	}
+	d.Structures[name] = s
+	return nil
+}
+
+// parseFacing 解析方向名。
+func parseFacing(s string) (int, bool) {
+	switch s {
+	case "north":
+		return dirNorth, true
+	case "east":
+		return dirEast, true
+	case "south":
+		return dirSouth, true
+	case "west":
+		return dirWest, true
+	case "up":
+		return dirUp, true
+	case "down":
+		return dirDown, true
+	}
+	return 0, false
+}
+
+// loadJigsawTemplate 读取模板 loc 的 .nbt 文件（1.21+ 位于 structure/，之前位于 structures/）。
+func loadJigsawTemplate(fsys fs.FS, loc string) (*JigsawTemplate, error) {
+	ns, p, _ := strings.Cut(loc, ":")
+	var root map[string]any
+	var err error
+	for _, dir := range []string{"structure", "structures"} {
+		f, e := fsys.Open(path.Join("data", ns, dir, p+".nbt"))
+		if e != nil {
+			err = e
+			continue
+		}
+		root, err = readNBT(f)
+		f.Close()
+		break
+	}
+	if root == nil {
+		return nil, fmt.Errorf("template %q: %w", loc, err)
+	}
+
+	t := &JigsawTemplate{Size: nbtPos(root["size"])}
+	palette, _ := root["palette"].([]any)
+	if palettes, ok := root["palettes"].([]any); ok && len(palettes) > 0 {
+		palette, _ = palettes[0].([]any)
+	}
+	blocks, _ := root["blocks"].([]any)
+	for _, b := range blocks {
+		block, _ := b.(map[string]any)
+		state := nbtInt(block["state"])
+		if state < 0 || state >= len(palette) {
+			return nil, fmt.Errorf("template %q: palette index %d out of range", loc, state)
+		}
+		entry, _ := palette[state].(map[string]any)
+		id, _ := entry["Name"].(string)
+		pos := nbtPos(block["pos"])
+		if id == "minecraft:jigsaw" {
+			props, _ := entry["Properties"].(map[string]any)
+			nbt, _ := block["nbt"].(map[string]any)
+			t.Jigsaws = append(t.Jigsaws, parseJigsawBlock(pos, props, nbt))
+		} else if jigsawMarkerBlocks[id] {
+			t.Markers = append(t.Markers, JigsawMarker{ID: id, Pos: pos})
+		}
+	}
+	// 原版加载模板时按 (y, x, z) 排序方块
+	sort.SliceStable(t.Jigsaws, func(i, j int) bool {
+		a, b := t.Jigsaws[i].Pos, t.Jigsaws[j].Pos
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		if a.X != b.X {
+			return a.X < b.X
+		}
+		return a.Z < b.Z
+	})
+	entities, _ := root["entities"].([]any)
+	for _, e := range entities {
+		entity, _ := e.(map[string]any)
+		nbt, _ := entity["nbt"].(map[string]any)
+		id, _ := nbt["id"].(string)
+		t.Markers = append(t.Markers, JigsawMarker{ID: id, Pos: nbtPos(entity["blockPos"]), Entity: true})
+	}
+	return t, nil
+}
+
+// parseJigsawBlock 由方块状态和方块实体 NBT 得到拼图方块。
+func parseJigsawBlock(pos Pos3, props, nbt map[string]any) JigsawConnector {
+	c := JigsawConnector{Pos: pos, Front: dirNorth, Top: dirUp}
+	if o, ok := props["orientation"].(string); ok {
+		front, top, _ := strings.Cut(o, "_")
+		c.Front, _ = parseFacing(front)
+		c.Top, _ = parseFacing(top)
+	} else if f, ok := props["facing"].(string); ok {
+		// 1.16 以前的拼图方块只有 facing
+		c.Front, _ = parseFacing(f)
+		if c.Front >= dirUp {
+			c.Top = dirNorth
+		}
+	}
+	str := func(k string) string {
+		s, _ := nbt[k].(string)
+		return s
+	}
+	c.Name, c.Target, c.Pool = jigsawKey(str("name")), jigsawKey(str("target")), jigsawKey(str("pool"))
+	if c.Name == "" && c.Target == "" {
+		// 1.14/1.15 用 attachement_type 同时作为名称和目标，连接时不检查顶面
+		c.Name = jigsawKey(str("attachement_type"))
+		c.Target = c.Name
+		c.Pool = jigsawKey(str("target_pool"))
+		c.Rollable = true
+	} else {
+		switch str("joint") {
+		case "rollable":
+			c.Rollable = true
+		case "aligned":
+		default:
+			c.Rollable = c.Front >= dirUp
+		}
+	}
+	c.SelectionPriority = nbtInt(nbt["selection_priority"])
+	c.PlacementPriority = nbtInt(nbt["placement_priority"])
+	return c
+}
@@ -0,0 +1,92 @@
+package gobiomes
+
+import (
+	"fmt"
+	"math"
+)
+
+// LocateVanillaRadius 是原版 /locate 搜索的 region 环数。
+const LocateVanillaRadius = 100
+
+// Locate 返回距离 from 最近（水平欧氏距离）且不超过 maxRadius 的可生成结构位置，没有找到时返回 nil, nil。
+// 以 from 所在的 region 为中心逐环向外搜索，当某一环与 from 的最小可能距离超过已找到的最近结构时停止，
+// 因此结果一定是最近的。距离相同时取 X 较小、其次 Z 较小的一个。
+func (gen *Generator) Locate(st StructureType, from Pos, maxRadius int) (*Pos, error) {
+	f := NewFinder(gen.Version)
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return nil, err
+	}
+	rb := int64(config.RegionSize) * 16
+	r0x, r0z := floorDiv(from.X, int(rb)), floorDiv(from.Z, int(rb))
+	r2 := int64(maxRadius) * int64(maxRadius)
+
+	var best *Pos
+	bestD := int64(math.MaxInt64)
+	for k := 0; ; k++ {
+		// 第 k 环的 region 在某一轴上与 from 所在 region 之间至少隔着 k-1 个完整 region
+		if k > 1 {
+			lb := int64(k-1) * rb
+			if lb*lb > r2 || lb*lb > bestD {
+				break
+			}
+		}
+		for dx := -k; dx <= k; dx++ {
+			for dz := -k; dz <= k; dz++ {
+				if dx != -k && dx != k && dz != -k && dz != k {
+					continue
+				}
+				p, err := gen.GetStructurePos(st, r0x+dx, r0z+dz)
+				if err != nil {
+					return nil, err
+				}
+				if p == nil {
+					continue
+				}
+				ddx, ddz := int64(p.X-from.X), int64(p.Z-from.Z)
+				d := ddx*ddx + ddz*ddz
+				if d > r2 || d > bestD {
+					continue
+				}
+				if d == bestD && (p.X > best.X || p.X == best.X && p.Z > best.Z) {
+					continue
+				}
+				best, bestD = p, d
+			}
+		}
+	}
+	return best, nil
+}
+
+// LocateVanilla 按原版 /locate（1.13+）的方式搜索：从 from 所在的 region 开始逐环搜索至多
+// LocateVanillaRadius 环，返回第一个包含可生成结构的环中按 X 偏移、再按 Z 偏移遍历时遇到的第一个结构。
+// 与游戏一致，结果不一定是最近的结构。
+func (gen *Generator) LocateVanilla(st StructureType, from Pos) (*Pos, error) {
+	if gen.Version < MC_1_13 {
+		return nil, fmt.Errorf("vanilla locate not supported in version %v", gen.Version)
+	}
+	f := NewFinder(gen.Version)
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return nil, err
+	}
+	rb := config.RegionSize * 16
+	r0x, r0z := floorDiv(from.X, rb), floorDiv(from.Z, rb)
+	for k := 0; k <= LocateVanillaRadius; k++ {
+		for dx := -k; dx <= k; dx++ {
+			for dz := -k; dz <= k; dz++ {
+				if dx != -k && dx != k && dz != -k && dz != k {
+					continue
+				}
+				p, err := gen.GetStructurePos(st, r0x+dx, r0z+dz)
+				if err != nil {
+					return nil, err
+				}
+				if p != nil {
+					return p, nil
+				}
+			}
+		}
+	}
+	return nil, nil
+}
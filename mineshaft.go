@@ -0,0 +1,390 @@
+package gobiomes
+
+// 废弃矿井部件类型，对应 MineShaftPieces 中的各部件类。
+const (
+	MineshaftRoom           = iota // 起始房间
+	MineshaftCorridor              // 走廊
+	MineshaftSpiderCorridor        // 带洞穴蜘蛛刷怪笼的走廊
+	MineshaftCrossing              // 十字路口
+	MineshaftStairs                // 楼梯
+)
+
+var mineshaftPieceNames = [...]string{
+	MineshaftRoom:           "mineshaft_room",
+	MineshaftCorridor:       "mineshaft_corridor",
+	MineshaftSpiderCorridor: "mineshaft_spider_corridor",
+	MineshaftCrossing:       "mineshaft_crossing",
+	MineshaftStairs:         "mineshaft_stairs",
+}
+
+// mineshaftGen 保存矿井部件生成过程中的状态。
+type mineshaftGen struct {
+	r      *Rng
+	pieces []Piece
+	startX int
+	startZ int
+}
+
+func (g *mineshaftGen) add(typ, dir, depth int, bb BoundingBox) Piece {
+	p := Piece{
+		Name:     mineshaftPieceNames[typ],
+		Type:     typ,
+		Pos:      Pos3{bb.MinX, bb.MinY, bb.MinZ},
+		BB:       bb,
+		Rotation: dir,
+		Depth:    depth,
+	}
+	g.pieces = append(g.pieces, p)
+	return p
+}
+
+// findCorridorSize 对应 MineShaftCorridor.findCorridorSize。
+func (g *mineshaftGen) findCorridorSize(x, y, z, dir int) (BoundingBox, bool) {
+	bb := BoundingBox{x, y, z, x, y + 2, z}
+	n := g.r.NextInt(3) + 2
+	for ; n > 0; n-- {
+		l := n * 5
+		switch dir {
+		case dirNorth:
+			bb.MaxX, bb.MinZ = x+2, z-(l-1)
+		case dirSouth:
+			bb.MaxX, bb.MaxZ = x+2, z+l-1
+		case dirWest:
+			bb.MinX, bb.MaxZ = x-(l-1), z+2
+		case dirEast:
+			bb.MaxX, bb.MaxZ = x+l-1, z+2
+		}
+		if findCollisionPiece(g.pieces, bb) < 0 {
+			break
+		}
+	}
+	return bb, n > 0
+}
+
+// findCrossing 对应 MineShaftCrossing.findCrossing。
+func (g *mineshaftGen) findCrossing(x, y, z, dir int) (BoundingBox, bool) {
+	bb := BoundingBox{x, y, z, x, y + 2, z}
+	if g.r.NextInt(4) == 0 {
+		bb.MaxY += 4
+	}
+	switch dir {
+	case dirNorth:
+		bb.MinX, bb.MaxX, bb.MinZ = x-1, x+3, z-4
+	case dirSouth:
+		bb.MinX, bb.MaxX, bb.MaxZ = x-1, x+3, z+4
+	case dirWest:
+		bb.MinX, bb.MinZ, bb.MaxZ = x-4, z-1, z+3
+	case dirEast:
+		bb.MaxX, bb.MinZ, bb.MaxZ = x+4, z-1, z+3
+	}
+	return bb, findCollisionPiece(g.pieces, bb) < 0
+}
+
+// findStairs 对应 MineShaftStairs.findStairs。
+func (g *mineshaftGen) findStairs(x, y, z, dir int) (BoundingBox, bool) {
+	bb := BoundingBox{x, y - 5, z, x, y + 2, z}
+	switch dir {
+	case dirNorth:
+		bb.MaxX, bb.MinZ = x+2, z-8
+	case dirSouth:
+		bb.MaxX, bb.MaxZ = x+2, z+8
+	case dirWest:
+		bb.MinX, bb.MaxZ = x-8, z+2
+	case dirEast:
+		bb.MaxX, bb.MaxZ = x+8, z+2
+	}
+	return bb, findCollisionPiece(g.pieces, bb) < 0
+}
+
+// generateAndAddPiece 对应 MineShaftPieces.generateAndAddPiece：创建随机部件后立即递归生成其子部件。
+func (g *mineshaftGen) generateAndAddPiece(x, y, z, dir, depth int) {
+	if depth > 8 {
+		return
+	}
+	if absInt(x-g.startX) > 80 || absInt(z-g.startZ) > 80 {
+		return
+	}
+	depth++
+	var p Piece
+	switch t := g.r.NextInt(100); {
+	case t >= 80:
+		bb, ok := g.findCrossing(x, y, z, dir)
+		if !ok {
+			return
+		}
+		p = g.add(MineshaftCrossing, dir, depth, bb)
+	case t >= 70:
+		bb, ok := g.findStairs(x, y, z, dir)
+		if !ok {
+			return
+		}
+		p = g.add(MineshaftStairs, dir, depth, bb)
+	default:
+		bb, ok := g.findCorridorSize(x, y, z, dir)
+		if !ok {
+			return
+		}
+		typ := MineshaftCorridor
+		hasRails := g.r.NextInt(3) == 0
+		if !hasRails && g.r.NextInt(23) == 0 {
+			typ = MineshaftSpiderCorridor
+		}
+		p = g.add(typ, dir, depth, bb)
+	}
+	g.addChildren(p)
+}
+
+// addChildren 对应各矿井部件的 addChildren。
+func (g *mineshaftGen) addChildren(p Piece) {
+	bb := p.BB
+	d := p.Depth
+	r := g.r
+
+	switch p.Type {
+	case MineshaftRoom:
+		ys := bb.MaxY - bb.MinY + 1 - 4
+		if ys <= 0 {
+			ys = 1
+		}
+		xs := bb.MaxX - bb.MinX + 1
+		zs := bb.MaxZ - bb.MinZ + 1
+		for k := 0; k < xs; k += 4 {
+			if k += r.NextInt(xs); k+3 > xs {
+				break
+			}
+			g.generateAndAddPiece(bb.MinX+k, bb.MinY+r.NextInt(ys)+1, bb.MinZ-1, dirNorth, d)
+		}
+		for k := 0; k < xs; k += 4 {
+			if k += r.NextInt(xs); k+3 > xs {
+				break
+			}
+			g.generateAndAddPiece(bb.MinX+k, bb.MinY+r.NextInt(ys)+1, bb.MaxZ+1, dirSouth, d)
+		}
+		for k := 0; k < zs; k += 4 {
+			if k += r.NextInt(zs); k+3 > zs {
+				break
+			}
+			g.generateAndAddPiece(bb.MinX-1, bb.MinY+r.NextInt(ys)+1, bb.MinZ+k, dirWest, d)
+		}
+		for k := 0; k < zs; k += 4 {
+			if k += r.NextInt(zs); k+3 > zs {
+				break
+			}
+			g.generateAndAddPiece(bb.MaxX+1, bb.MinY+r.NextInt(ys)+1, bb.MinZ+k, dirEast, d)
+		}
+
+	case MineshaftCorridor, MineshaftSpiderCorridor:
+		j := r.NextInt(4)
+		switch p.Rotation {
+		case dirNorth:
+			if j <= 1 {
+				g.generateAndAddPiece(bb.MinX, bb.MinY-1+r.NextInt(3), bb.MinZ-1, dirNorth, d)
+			} else if j == 2 {
+				g.generateAndAddPiece(bb.MinX-1, bb.MinY-1+r.NextInt(3), bb.MinZ, dirWest, d)
+			} else {
+				g.generateAndAddPiece(bb.MaxX+1, bb.MinY-1+r.NextInt(3), bb.MinZ, dirEast, d)
+			}
+		case dirSouth:
+			if j <= 1 {
+				g.generateAndAddPiece(bb.MinX, bb.MinY-1+r.NextInt(3), bb.MaxZ+1, dirSouth, d)
+			} else if j == 2 {
+				g.generateAndAddPiece(bb.MinX-1, bb.MinY-1+r.NextInt(3), bb.MaxZ-3, dirWest, d)
+			} else {
+				g.generateAndAddPiece(bb.MaxX+1, bb.MinY-1+r.NextInt(3), bb.MaxZ-3, dirEast, d)
+			}
+		case dirWest:
+			if j <= 1 {
+				g.generateAndAddPiece(bb.MinX-1, bb.MinY-1+r.NextInt(3), bb.MinZ, dirWest, d)
+			} else if j == 2 {
+				g.generateAndAddPiece(bb.MinX, bb.MinY-1+r.NextInt(3), bb.MinZ-1, dirNorth, d)
+			} else {
+				g.generateAndAddPiece(bb.MinX, bb.MinY-1+r.NextInt(3), bb.MaxZ+1, dirSouth, d)
+			}
+		case dirEast:
+			if j <= 1 {
+				g.generateAndAddPiece(bb.MaxX+1, bb.MinY-1+r.NextInt(3), bb.MinZ, dirEast, d)
+			} else if j == 2 {
+				g.generateAndAddPiece(bb.MaxX-3, bb.MinY-1+r.NextInt(3), bb.MinZ-1, dirNorth, d)
+			} else {
+				g.generateAndAddPiece(bb.MaxX-3, bb.MinY-1+r.NextInt(3), bb.MaxZ+1, dirSouth, d)
+			}
+		}
+		if d < 8 {
+			if p.Rotation == dirNorth || p.Rotation == dirSouth {
+				for k := bb.MinZ + 3; k+3 <= bb.MaxZ; k += 5 {
+					switch r.NextInt(5) {
+					case 0:
+						g.generateAndAddPiece(bb.MinX-1, bb.MinY, k, dirWest, d+1)
+					case 1:
+						g.generateAndAddPiece(bb.MaxX+1, bb.MinY, k, dirEast, d+1)
+					}
+				}
+			} else {
+				for k := bb.MinX + 3; k+3 <= bb.MaxX; k += 5 {
+					switch r.NextInt(5) {
+					case 0:
+						g.generateAndAddPiece(k, bb.MinY, bb.MinZ-1, dirNorth, d+1)
+					case 1:
+						g.generateAndAddPiece(k, bb.MinY, bb.MaxZ+1, dirSouth, d+1)
+					}
+				}
+			}
+		}
+
+	case MineshaftCrossing:
+		switch p.Rotation {
+		case dirNorth:
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MinZ-1, dirNorth, d)
+			g.generateAndAddPiece(bb.MinX-1, bb.MinY, bb.MinZ+1, dirWest, d)
+			g.generateAndAddPiece(bb.MaxX+1, bb.MinY, bb.MinZ+1, dirEast, d)
+		case dirSouth:
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MaxZ+1, dirSouth, d)
+			g.generateAndAddPiece(bb.MinX-1, bb.MinY, bb.MinZ+1, dirWest, d)
+			g.generateAndAddPiece(bb.MaxX+1, bb.MinY, bb.MinZ+1, dirEast, d)
+		case dirWest:
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MinZ-1, dirNorth, d)
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MaxZ+1, dirSouth, d)
+			g.generateAndAddPiece(bb.MinX-1, bb.MinY, bb.MinZ+1, dirWest, d)
+		case dirEast:
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MinZ-1, dirNorth, d)
+			g.generateAndAddPiece(bb.MinX+1, bb.MinY, bb.MaxZ+1, dirSouth, d)
+			g.generateAndAddPiece(bb.MaxX+1, bb.MinY, bb.MinZ+1, dirEast, d)
+		}
+		if bb.MaxY-bb.MinY+1 > 3 {
+			if r.Next(1) != 0 {
+				g.generateAndAddPiece(bb.MinX+1, bb.MinY+4, bb.MinZ-1, dirNorth, d)
+			}
+			if r.Next(1) != 0 {
+				g.generateAndAddPiece(bb.MinX-1, bb.MinY+4, bb.MinZ+1, dirWest, d)
+			}
+			if r.Next(1) != 0 {
+				g.generateAndAddPiece(bb.MaxX+1, bb.MinY+4, bb.MinZ+1, dirEast, d)
+			}
+			if r.Next(1) != 0 {
+				g.generateAndAddPiece(bb.MinX+1, bb.MinY+4, bb.MaxZ+1, dirSouth, d)
+			}
+		}
+
+	case MineshaftStairs:
+		switch p.Rotation {
+		case dirNorth:
+			g.generateAndAddPiece(bb.MinX, bb.MinY, bb.MinZ-1, dirNorth, d)
+		case dirSouth:
+			g.generateAndAddPiece(bb.MinX, bb.MinY, bb.MaxZ+1, dirSouth, d)
+		case dirWest:
+			g.generateAndAddPiece(bb.MinX-1, bb.MinY, bb.MinZ, dirWest, d)
+		case dirEast:
+			g.generateAndAddPiece(bb.MaxX+1, bb.MinY, bb.MinZ, dirEast, d)
+		}
+	}
+}
+
+// mineshaftStartRng 返回矿井 StructureStart 使用的随机数生成器。
+// 1.13 之前起始部件沿用判定矿井所用的 chunk 随机数；之后使用新的 chunk 生成种子。
+// 1.19+ 的 findGenerationPoint 在组装部件前先在同一个随机数上调用一次 nextDouble。
+func (f *Finder) mineshaftStartRng(seed uint64, chunkX, chunkZ int) *Rng {
+	r := &Rng{seed: f.ChunkGenerateRnd(seed, chunkX, chunkZ)}
+	if f.Version <= MC_1_12 {
+		r.SkipNextN(1)
+		r.NextDouble()
+		r.NextInt(80)
+	}
+	if f.Version >= MC_1_19_2 {
+		r.NextDouble()
+	}
+	return r
+}
+
+// IsMineshaftMesa 判断起点位于生物群系 biome 的废弃矿井是否为恶地矿井。
+// 1.10 ~ 1.17 为所有恶地类生物群系；1.18+ 对应 has_structure/mineshaft_mesa（#is_badlands）。
+func IsMineshaftMesa(mc int, biome Biome) bool {
+	switch {
+	case mc < MC_1_10:
+		return false
+	case mc >= MC_1_18:
+		// is_badlands 标签；1.18 的采样结果对繁茂的恶地使用旧 ID
+		return biome == Badlands || biome == ErodedBadlands || biome == WoodedBadlands || biome == WoodedBadlandsPlateau
+	}
+	return biome.IsMesa()
+}
+
+// GetMineshaftPieces 生成废弃矿井在 chunk (chunkX, chunkZ) 处的全部部件，对应 MineShaftStart.generatePieces。
+// mesa 表示恶地矿井，见 IsMineshaftMesa。
+// 1.18+ 的恶地矿井高度取决于地形，这里按地表不高于海平面处理。
+func (f *Finder) GetMineshaftPieces(seed uint64, chunkX, chunkZ int, mesa bool) []Piece {
+	mc := f.Version
+	g := &mineshaftGen{r: f.mineshaftStartRng(seed, chunkX, chunkZ)}
+	r := g.r
+
+	x := chunkX<<4 + 2
+	z := chunkZ<<4 + 2
+	x1 := x + 7 + r.NextInt(6)
+	y1 := 54 + r.NextInt(6)
+	z1 := z + 7 + r.NextInt(6)
+	g.startX, g.startZ = x, z
+	room := g.add(MineshaftRoom, dirNorth, 0, BoundingBox{x, 50, z, x1, y1, z1})
+	g.addChildren(room)
+
+	const seaLevel = 63
+	bb := PiecesBoundingBox(g.pieces)
+	ySpan := bb.MaxY - bb.MinY + 1
+	var dy int
+	switch {
+	case mesa && mc >= MC_1_10 && mc <= MC_1_17:
+		dy = seaLevel - bb.MaxY + ySpan/2 + 5
+	case mesa && mc >= MC_1_18:
+		// BoundingBox.getCenter
+		dy = seaLevel - (bb.MinY + (bb.MaxY-bb.MinY+1)/2)
+	default:
+		minY := 0
+		if mc >= MC_1_18 {
+			minY = -64
+		}
+		top := seaLevel - 10
+		y := ySpan + minY + 1
+		if y < top {
+			y += r.NextInt(top - y)
+		}
+		dy = y - bb.MaxY
+	}
+	for i := range g.pieces {
+		g.pieces[i].Pos.Y += dy
+		g.pieces[i].BB.MinY += dy
+		g.pieces[i].BB.MaxY += dy
+	}
+	return g.pieces
+}
+
+// GetMineshaftSpawners 返回矿井中洞穴蜘蛛刷怪笼的 block 坐标。
+// 刷怪笼位于蜘蛛走廊第一节的中轴上；其沿走廊方向的位置由装饰阶段随机数在 ±1 格内选取，
+// 且要求该处不被地形破坏，这里返回候选范围的中心。
+func GetMineshaftSpawners(pieces []Piece) []Pos3 {
+	var out []Pos3
+	for i := range pieces {
+		if pieces[i].Type == MineshaftSpiderCorridor {
+			out = append(out, pieceWorldPos(&pieces[i], 1, 0, 2))
+		}
+	}
+	return out
+}
+
+// GetMineshaftChestSlots 返回矿井走廊中可能生成运输矿车（带箱子的矿车）的位置。
+// 每节走廊两侧各有一个位置，由装饰阶段随机数以 1/100 的概率决定是否生成，无法仅由种子确定。
+func GetMineshaftChestSlots(pieces []Piece) []Pos3 {
+	var out []Pos3
+	for i := range pieces {
+		p := &pieces[i]
+		if p.Type != MineshaftCorridor && p.Type != MineshaftSpiderCorridor {
+			continue
+		}
+		span := p.BB.MaxZ - p.BB.MinZ + 1
+		if p.Rotation == dirWest || p.Rotation == dirEast {
+			span = p.BB.MaxX - p.BB.MinX + 1
+		}
+		for m := 0; m < span/5; m++ {
+			n := 2 + m*5
+			out = append(out, pieceWorldPos(p, 2, 0, n-1), pieceWorldPos(p, 0, 0, n+1))
+		}
+	}
+	return out
+}
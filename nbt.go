@@ -0,0 +1,180 @@
+package gobiomes
+
+import (
+	"bufio"
+	"compress/gzip"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// NBT 标签类型。
+const (
+	nbtTagEnd = iota
+	nbtTagByte
+	nbtTagShort
+	nbtTagInt
+	nbtTagLong
+	nbtTagFloat
+	nbtTagDouble
+	nbtTagByteArray
+	nbtTagString
+	nbtTagList
+	nbtTagCompound
+	nbtTagIntArray
+	nbtTagLongArray
+)
+
+// readNBT 读取 gzip 压缩的 NBT 文件（结构模板 .nbt），返回根 compound。
+// 标签值映射为 int8/int16/int32/int64/float32/float64/[]byte/string/[]any/map[string]any/[]int32/[]int64。
+func readNBT(r io.Reader) (map[string]any, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	d := nbtDecoder{r: bufio.NewReader(zr)}
+	tag := d.u8()
+	if tag != nbtTagCompound {
+		return nil, fmt.Errorf("nbt: root tag is %d, not a compound", tag)
+	}
+	d.str()
+	v := d.value(nbtTagCompound, 0)
+	if d.err != nil {
+		return nil, d.err
+	}
+	return v.(map[string]any), nil
+}
+
+// nbtDecoder 按大端序解码 NBT，遇到错误后记录在 err 中并返回零值。
+type nbtDecoder struct {
+	r   *bufio.Reader
+	err error
+	buf [8]byte
+}
+
+func (d *nbtDecoder) read(n int) []byte {
+	if d.err != nil {
+		return d.buf[:n]
+	}
+	_, d.err = io.ReadFull(d.r, d.buf[:n])
+	return d.buf[:n]
+}
+
+func (d *nbtDecoder) u8() byte    { return d.read(1)[0] }
+func (d *nbtDecoder) u16() uint16 { return binary.BigEndian.Uint16(d.read(2)) }
+func (d *nbtDecoder) u32() uint32 { return binary.BigEndian.Uint32(d.read(4)) }
+func (d *nbtDecoder) u64() uint64 { return binary.BigEndian.Uint64(d.read(8)) }
+
+func (d *nbtDecoder) length() int {
+	n := int32(d.u32())
+	if n < 0 && d.err == nil {
+		d.err = fmt.Errorf("nbt: negative length %d", n)
+	}
+	return max(int(n), 0)
+}
+
+func (d *nbtDecoder) str() string {
+	n := int(d.u16())
+	if d.err != nil {
+		return ""
+	}
+	b := make([]byte, n)
+	_, d.err = io.ReadFull(d.r, b)
+	return string(b)
+}
+
+func (d *nbtDecoder) value(tag byte, depth int) any {
+	if depth > 512 {
+		d.err = fmt.Errorf("nbt: nesting too deep")
+	}
+	if d.err != nil {
+		return nil
+	}
+	switch tag {
+	case nbtTagByte:
+		return int8(d.u8())
+	case nbtTagShort:
+		return int16(d.u16())
+	case nbtTagInt:
+		return int32(d.u32())
+	case nbtTagLong:
+		return int64(d.u64())
+	case nbtTagFloat:
+		return math.Float32frombits(d.u32())
+	case nbtTagDouble:
+		return math.Float64frombits(d.u64())
+	case nbtTagByteArray:
+		b := make([]byte, d.length())
+		if d.err == nil {
+			_, d.err = io.ReadFull(d.r, b)
+		}
+		return b
+	case nbtTagString:
+		return d.str()
+	case nbtTagList:
+		elem := d.u8()
+		n := d.length()
+		list := make([]any, 0, min(n, 1024))
+		for i := 0; i < n && d.err == nil; i++ {
+			list = append(list, d.value(elem, depth+1))
+		}
+		return list
+	case nbtTagCompound:
+		m := map[string]any{}
+		for d.err == nil {
+			t := d.u8()
+			if t == nbtTagEnd {
+				break
+			}
+			name := d.str()
+			m[name] = d.value(t, depth+1)
+		}
+		return m
+	case nbtTagIntArray:
+		n := d.length()
+		a := make([]int32, 0, min(n, 1024))
+		for i := 0; i < n && d.err == nil; i++ {
+			a = append(a, int32(d.u32()))
+		}
+		return a
+	case nbtTagLongArray:
+		n := d.length()
+		a := make([]int64, 0, min(n, 1024))
+		for i := 0; i < n && d.err == nil; i++ {
+			a = append(a, int64(d.u64()))
+		}
+		return a
+	}
+	d.err = fmt.Errorf("nbt: unknown tag %d", tag)
+	return nil
+}
+
+// nbtInt 把整数或浮点标签转换为 int，其他类型返回 0。
+func nbtInt(v any) int {
+	switch x := v.(type) {
+	case int8:
+		return int(x)
+	case int16:
+		return int(x)
+	case int32:
+		return int(x)
+	case int64:
+		return int(x)
+	case float32:
+		return int(math.Floor(float64(x)))
+	case float64:
+		return int(math.Floor(x))
+	}
+	return 0
+}
+
+// nbtPos 把 [x, y, z] 列表转换为 Pos3。
+func nbtPos(v any) Pos3 {
+	list, _ := v.([]any)
+	if len(list) < 3 {
+		return Pos3{}
+	}
+	return Pos3{nbtInt(list[0]), nbtInt(list[1]), nbtInt(list[2])}
+}
@@ -0,0 +1,288 @@
+package gobiomes
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// 四联基值 (quad base) 是结构种子的低 48 位加上结构的 salt：
+// region (regX, regZ) 内的位置只取决于 base + regX*341873128712 + regZ*132897987541，
+// 因此同一个基值对 region 配置相同的结构（例如女巫小屋和掠夺者前哨站）都成立，
+// 也可以通过 QuadBaseSeed 平移到任意 region。
+// 四联指 region (0,0), (1,0), (0,1), (1,1) 中的结构聚集在它们共同的角上。
+
+// quadFootprint 返回结构相对生成尝试位置的水平占地（含两端，不依赖旋转的保守值）。
+func quadFootprint(mc int, st StructureType) (x0, x1 int) {
+	switch st {
+	case SwampHut:
+		return 0, 8
+	case Monument:
+		if mc <= MC_1_12 {
+			return -21, 36
+		}
+		return -29, 28
+	}
+	return 0, 15
+}
+
+// quadTriangular 判断结构在该版本是否使用两次 nextInt 取平均的 region 内偏移。
+func quadTriangular(mc int, st StructureType) bool {
+	switch st {
+	case Mansion, EndCity:
+		return true
+	case Monument:
+		return mc < MC_1_18
+	}
+	return false
+}
+
+// quadChunk 返回基值 base 下 region (regX, regZ) 内的 chunk 偏移。
+func (f *Finder) quadChunk(st StructureType, config StructureConfig, base uint64, regX, regZ int) (int, int) {
+	config.Salt = 0
+	if quadTriangular(f.Version, st) {
+		return getLargeStructureChunkInRegion(config, base, regX, regZ)
+	}
+	return getFeatureChunkInRegion(config, base, regX, regZ)
+}
+
+// QuadBaseSeed 返回把基值 base 描述的四联放到 region (regX, regZ) ~ (regX+1, regZ+1) 的 48 位结构种子。
+func (f *Finder) QuadBaseSeed(st StructureType, base uint64, regX, regZ int) (uint64, error) {
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return 0, err
+	}
+	return (base - uint64(config.Salt) - uint64(regX)*341873128712 - uint64(regZ)*132897987541) & mask48, nil
+}
+
+// QuadRadius 返回基值 base 下四个 region 中结构占地的最小包围圆半径（水平 block 距离），以及圆心。
+func (f *Finder) QuadRadius(st StructureType, base uint64) (float64, Pos, error) {
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return 0, Pos{}, err
+	}
+	r, c := f.quadRadius(st, config, base)
+	return r, c, nil
+}
+
+func (f *Finder) quadRadius(st StructureType, config StructureConfig, base uint64) (float64, Pos) {
+	fx0, fx1 := quadFootprint(f.Version, st)
+	pts := make([][2]float64, 0, 16)
+	for i := 0; i < 4; i++ {
+		rx, rz := i&1, i>>1
+		px, pz := f.quadChunk(st, config, base, rx, rz)
+		x := float64((rx*config.RegionSize + px) * 16)
+		z := float64((rz*config.RegionSize + pz) * 16)
+		for _, dx := range [2]int{fx0, fx1 + 1} {
+			for _, dz := range [2]int{fx0, fx1 + 1} {
+				pts = append(pts, [2]float64{x + float64(dx), z + float64(dz)})
+			}
+		}
+	}
+	r, cx, cz := enclosingCircle(pts)
+	return r, Pos{int(math.Floor(cx)), int(math.Floor(cz))}
+}
+
+// enclosingCircle 返回点集的最小包围圆（点数很少，直接枚举由两点或三点确定的圆）。
+func enclosingCircle(pts [][2]float64) (r, cx, cz float64) {
+	const eps = 1e-7
+	best := math.Inf(1)
+	covers := func(x, z, rr float64) bool {
+		for _, p := range pts {
+			dx, dz := p[0]-x, p[1]-z
+			if dx*dx+dz*dz > rr+eps {
+				return false
+			}
+		}
+		return true
+	}
+	try := func(x, z, rr float64) {
+		if rr < best && covers(x, z, rr) {
+			best, cx, cz = rr, x, z
+		}
+	}
+	for i := range pts {
+		for j := i + 1; j < len(pts); j++ {
+			x := (pts[i][0] + pts[j][0]) / 2
+			z := (pts[i][1] + pts[j][1]) / 2
+			dx, dz := pts[i][0]-x, pts[i][1]-z
+			try(x, z, dx*dx+dz*dz)
+			for k := j + 1; k < len(pts); k++ {
+				ax, az := pts[i][0], pts[i][1]
+				bx, bz := pts[j][0]-ax, pts[j][1]-az
+				qx, qz := pts[k][0]-ax, pts[k][1]-az
+				d := 2 * (bx*qz - bz*qx)
+				if math.Abs(d) < eps {
+					continue
+				}
+				b2, q2 := bx*bx+bz*bz, qx*qx+qz*qz
+				ux := (qz*b2 - bz*q2) / d
+				uz := (bx*q2 - qx*b2) / d
+				try(ax+ux, az+uz, ux*ux+uz*uz)
+			}
+		}
+	}
+	return math.Sqrt(best), cx, cz
+}
+
+// IsQuadBase 判断基值 base 是否构成四联：四个结构占地都在半径 radius 的圆内。
+func (f *Finder) IsQuadBase(st StructureType, base uint64, radius float64) (bool, error) {
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return false, err
+	}
+	return f.isQuadBase(st, config, base&mask48, radius), nil
+}
+
+func (f *Finder) isQuadBase(st StructureType, config StructureConfig, base uint64, radius float64) bool {
+	lo, hi := f.quadAxisRange(st, config, radius)
+	if lo > hi {
+		return false
+	}
+	// 先用 chunk 偏移做必要条件检查，再计算包围圆
+	var p [4][2]int
+	for i := 0; i < 4; i++ {
+		rx, rz := i&1, i>>1
+		p[i][0], p[i][1] = f.quadChunk(st, config, base, rx, rz)
+		if !quadAxisOK(p[i][0], rx, lo, hi, config.ChunkRange) || !quadAxisOK(p[i][1], rz, lo, hi, config.ChunkRange) {
+			return false
+		}
+	}
+	fx0, fx1 := quadFootprint(f.Version, st)
+	w := fx1 - fx0 + 1
+	span := func(near, far int) float64 {
+		return float64((config.RegionSize+far-near)*16 + w)
+	}
+	d2 := 4 * radius * radius
+	if sx, sz := span(p[0][0], p[3][0]), span(p[0][1], p[3][1]); sx*sx+sz*sz > d2 {
+		return false
+	}
+	if sx, sz := span(p[2][0], p[1][0]), span(p[1][1], p[2][1]); sx*sx+sz*sz > d2 {
+		return false
+	}
+	r, _ := f.quadRadius(st, config, base)
+	return r <= radius
+}
+
+// quadAxisRange 返回四联在单个轴上的必要条件：靠前 region 的偏移需 >= lo，靠后 region 的偏移需 <= ChunkRange-1-lo。
+// 两个结构在该轴上的跨度为 (RegionSize + p1 - p0)*16 + 占地宽度，不能超过直径。
+func (f *Finder) quadAxisRange(st StructureType, config StructureConfig, radius float64) (int, int) {
+	fx0, fx1 := quadFootprint(f.Version, st)
+	w := float64(fx1 - fx0 + 1)
+	lo := int(math.Ceil(float64(config.RegionSize) - (2*radius-w)/16))
+	return max(lo, 0), config.ChunkRange - 1
+}
+
+func quadAxisOK(p, r, lo, hi, chunkRange int) bool {
+	if r == 0 {
+		return p >= lo && p <= hi
+	}
+	return p <= chunkRange-1-lo
+}
+
+// quadLow20OK 用基值的低 20 位做预筛选：next(31) 的低 3 位只取决于种子的低 20 位，
+// 因此当 ChunkRange 能被 8（或 4、2）整除时，偏移对该数取模的值由低 20 位确定。
+// 由余数得到每个偏移可能的最大/最小值，再检查两条对角线上的结构能否落在直径 2*radius 内。
+// 忽略了 nextInt 极少发生的拒绝重采样，与 getFeatureChunkInRegion 一致。
+func (f *Finder) quadLow20OK(st StructureType, config StructureConfig, low uint64, radius float64) bool {
+	const mask20 = (1 << 20) - 1
+	cr := config.ChunkRange
+	g := min(cr&-cr, 8)
+	if cr&(cr-1) == 0 {
+		// 2 的幂使用高位，低 20 位无法提供信息
+		g = 1
+	}
+	// 每个 region 在两个轴上偏移的 [最小, 最大] 可能值
+	var rng [4][2][2]int
+	for i := 0; i < 4; i++ {
+		rx, rz := i&1, i>>1
+		s := (low + uint64(rx)*341873128712 + uint64(rz)*132897987541) & mask20
+		s = (s ^ 0x5deece66d) & mask20
+		for a := 0; a < 2; a++ {
+			s = (s*0x5deece66d + 0xb) & mask20
+			m := int(s>>17) & (g - 1)
+			rng[i][a] = [2]int{m, m + (cr-1-m)/g*g}
+		}
+	}
+	fx0, fx1 := quadFootprint(f.Version, st)
+	w := fx1 - fx0 + 1
+	span := func(near, far int) float64 {
+		return float64((config.RegionSize+far-near)*16 + w)
+	}
+	d2 := 4 * radius * radius
+	// 对角线 (0,0)-(1,1) 与 (1,0)-(0,1)
+	sx := span(rng[0][0][1], rng[3][0][0])
+	sz := span(rng[0][1][1], rng[3][1][0])
+	if sx*sx+sz*sz > d2 {
+		return false
+	}
+	sx = span(rng[2][0][1], rng[1][0][0])
+	sz = span(rng[1][1][1], rng[2][1][0])
+	return sx*sx+sz*sz <= d2
+}
+
+// SearchQuadBases 在全部 2^48 个基值中搜索半径不超过 radius 的四联，结果通过返回的 channel 逐个输出，
+// 搜索结束或 ctx 被取消时关闭。先按低 20 位预筛选，再对每个通过的低 20 位枚举高 28 位。
+// 只支持 region 内偏移为单次 nextInt 的结构（女巫小屋、前哨站、1.18+ 的海底神殿等）。
+// 基值可用 QuadBaseSeed 转换为结构种子，结构种子还需检查生物群系等条件。
+func (f *Finder) SearchQuadBases(ctx context.Context, st StructureType, radius float64, workers int) (<-chan uint64, error) {
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return nil, err
+	}
+	if quadTriangular(f.Version, st) {
+		return nil, fmt.Errorf("quad base search not supported for %v in version %v", st, f.Version)
+	}
+	lo, hi := f.quadAxisRange(st, config, radius)
+	if lo > hi {
+		return nil, fmt.Errorf("radius %.1f too small for a quad of %v", radius, st)
+	}
+	if workers < 1 {
+		workers = 1
+	}
+
+	out := make(chan uint64, 64)
+	lows := make(chan uint64, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for low := range lows {
+				for high := uint64(0); high < 1<<28; high++ {
+					if high&0xffff == 0 && ctx.Err() != nil {
+						return
+					}
+					base := high<<20 | low
+					if !f.isQuadBase(st, config, base, radius) {
+						continue
+					}
+					select {
+					case out <- base:
+					case <-ctx.Done():
+						return
+					}
+				}
+			}
+		}()
+	}
+	go func() {
+		defer close(lows)
+		for low := uint64(0); low < 1<<20; low++ {
+			if !f.quadLow20OK(st, config, low, radius) {
+				continue
+			}
+			select {
+			case lows <- low:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
@@ -0,0 +1,51 @@
+package gobiomes
+
+import (
+	"math"
+	"testing"
+)
+
+func TestQuadBaseRoundTrip(t *testing.T) {
+	// SearchQuadBases(SwampHut, 128) 找到的第一个基值，四个小屋的包围圆半径约 127.9
+	const base = 0x8a600371d
+	for _, mc := range []int{MC_1_12, MC_1_16_5, MC_1_20_6} {
+		f := NewFinder(mc)
+		if ok, err := f.IsQuadBase(SwampHut, base, 128); err != nil || !ok {
+			t.Errorf("mc %d: IsQuadBase(%#x, 128) = %v, %v; want true", mc, base, ok, err)
+		}
+		if ok, _ := f.IsQuadBase(SwampHut, base, 120); ok {
+			t.Errorf("mc %d: IsQuadBase(%#x, 120) = true, want false", mc, base)
+		}
+		config, _ := f.GetStructureConfig(SwampHut)
+		if !f.quadLow20OK(SwampHut, config, base&(1<<20-1), 128) {
+			t.Errorf("mc %d: low 20 bit filter rejects %#x", mc, base)
+		}
+		r, c, err := f.QuadRadius(SwampHut, base)
+		if err != nil || r > 128 || r < 127 {
+			t.Fatalf("mc %d: QuadRadius = %v, %v, %v", mc, r, c, err)
+		}
+
+		for _, reg := range [][2]int{{0, 0}, {-1, -1}, {5, -3}} {
+			seed, err := f.QuadBaseSeed(SwampHut, base, reg[0], reg[1])
+			if err != nil {
+				t.Fatal(err)
+			}
+			// 用 GetStructurePos 独立取得小屋位置，检查 9x9 的占地都落在平移后的圆内（圆心取整误差不超过 √2）
+			cx := float64(c.X + reg[0]*config.RegionSize*16)
+			cz := float64(c.Z + reg[1]*config.RegionSize*16)
+			for i := 0; i < 4; i++ {
+				p, err := f.GetStructurePos(SwampHut, seed, reg[0]+(i&1), reg[1]+(i>>1))
+				if err != nil || p == nil {
+					t.Fatalf("mc %d seed %d: no hut in region %d: %v", mc, seed, i, err)
+				}
+				for _, dx := range []int{0, 9} {
+					for _, dz := range []int{0, 9} {
+						if d := math.Hypot(float64(p.X+dx)-cx, float64(p.Z+dz)-cz); d > 128+math.Sqrt2 {
+							t.Errorf("mc %d seed %d: hut %v corner (%d, %d) is %.1f from the centre", mc, seed, *p, dx, dz, d)
+						}
+					}
+				}
+			}
+		}
+	}
+}
@@ -0,0 +1,458 @@
+package gobiomes
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// CustomStructureBase 是自定义结构类型编号的起点，RegisterStructure 返回的类型依次递增。
+const CustomStructureBase StructureType = 1000
+
+// SpreadType 对应 random_spread 放置的 spread_type。
+type SpreadType int
+
+const (
+	SpreadLinear     SpreadType = iota // 单次 nextInt
+	SpreadTriangular                   // 两次 nextInt 取平均
+)
+
+// FrequencyReduction 对应 structure_set 的 frequency_reduction_method。
+type FrequencyReduction int
+
+const (
+	FreqDefault FrequencyReduction = iota
+	FreqLegacyType1
+	FreqLegacyType2
+	FreqLegacyType3
+)
+
+// CustomStructure 描述一个 random_spread 结构集（例如模组或数据包添加的结构）。
+type CustomStructure struct {
+	Name       string // 例如 "mymod:tower"
+	Salt       int32
+	Spacing    int // region 大小（chunk）
+	Separation int // 相邻结构的最小间隔（chunk），region 内偏移范围为 Spacing-Separation
+	Spread     SpreadType
+	Frequency  float32 // 0 或 >= 1 表示每次尝试都生成
+	Reduction  FrequencyReduction
+	Dim        Dimension
+	BiomeTags  []string // 可生成的生物群系 ID 或 "#" 开头的标签，例如 "#minecraft:is_forest"，注册时解析并并入 Biomes
+	Biomes     []Biome  // 可生成的生物群系，与 BiomeTags 都为空时不限制
+}
+
+var (
+	// ErrUnsupportedPlacement 表示 structure_set 使用了 random_spread 以外的放置类型。
+	ErrUnsupportedPlacement = errors.New("unsupported placement type")
+	// ErrNoKnownBiomes 表示结构的生物群系全部是本包不会生成的生物群系（例如模组添加的）。
+	ErrNoKnownBiomes = errors.New("no supported biomes")
+)
+
+var (
+	registryMu sync.RWMutex
+	registry   []CustomStructure
+	biomeTags  = map[string][]biomeTagEntry{}
+)
+
+// biomeTagEntry 是生物群系标签中的一项。
+type biomeTagEntry struct {
+	id       string // 生物群系 ID 或 "#" 开头的标签
+	optional bool   // required 为 false：不存在时忽略
+}
+
+// RegisterStructure 注册自定义结构，返回其 StructureType。同名结构已注册时替换原有定义并返回原来的类型。
+// BiomeTags 中的标签需要先用 LoadBiomeTags 加载，本包不会生成的生物群系 ID 会被忽略。
+// 注册后 Finder.GetStructureConfig、GetStructurePos 和 Generator.IsViableStructurePos 等接口都可以使用该类型。
+func RegisterStructure(cs CustomStructure) (StructureType, error) {
+	if cs.Spacing <= 0 || cs.Separation < 0 || cs.Separation >= cs.Spacing {
+		return 0, fmt.Errorf("invalid spacing %d and separation %d for structure %q", cs.Spacing, cs.Separation, cs.Name)
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	biomes, err := resolveBiomeTags(cs.BiomeTags)
+	if err != nil {
+		return 0, fmt.Errorf("structure %q: %w", cs.Name, err)
+	}
+	cs.Biomes = append(append([]Biome(nil), cs.Biomes...), biomes...)
+	cs.BiomeTags = append([]string(nil), cs.BiomeTags...)
+	if cs.Name != "" {
+		for i := range registry {
+			if registry[i].Name == cs.Name {
+				registry[i] = cs
+				return CustomStructureBase + StructureType(i), nil
+			}
+		}
+	}
+	registry = append(registry, cs)
+	return CustomStructureBase + StructureType(len(registry)-1), nil
+}
+
+// resolveBiomeTags 把生物群系 ID 和标签展开为生物群系列表，调用者需要持有 registryMu。
+func resolveBiomeTags(entries []string) ([]Biome, error) {
+	if len(entries) == 0 {
+		return nil, nil
+	}
+	var out []Biome
+	seenBiome := map[Biome]bool{}
+	seenTag := map[string]bool{}
+	var walk func(entries []biomeTagEntry) error
+	walk = func(entries []biomeTagEntry) error {
+		for _, e := range entries {
+			tag, isTag := strings.CutPrefix(e.id, "#")
+			if !isTag {
+				if b, ok := BiomeFromID(e.id); ok && !seenBiome[b] {
+					seenBiome[b] = true
+					out = append(out, b)
+				}
+				continue
+			}
+			tag = resourceKey(tag)
+			if seenTag[tag] {
+				continue
+			}
+			seenTag[tag] = true
+			values, ok := biomeTags[tag]
+			if !ok {
+				if e.optional {
+					continue
+				}
+				return fmt.Errorf("unknown biome tag %q", "#"+tag)
+			}
+			if err := walk(values); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	es := make([]biomeTagEntry, len(entries))
+	for i, id := range entries {
+		es[i] = biomeTagEntry{id: id}
+	}
+	if err := walk(es); err != nil {
+		return nil, err
+	}
+	if len(out) == 0 {
+		return nil, ErrNoKnownBiomes
+	}
+	return out, nil
+}
+
+// resourceKey 为资源名补全默认命名空间。
+func resourceKey(s string) string {
+	if strings.Contains(s, ":") {
+		return s
+	}
+	return "minecraft:" + s
+}
+
+// LookupStructure 返回名称为 name 的已注册自定义结构类型。
+func LookupStructure(name string) (StructureType, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	for i, cs := range registry {
+		if cs.Name == name {
+			return CustomStructureBase + StructureType(i), true
+		}
+	}
+	return 0, false
+}
+
+// GetCustomStructure 返回已注册的自定义结构。
+func GetCustomStructure(st StructureType) (CustomStructure, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	i := int(st - CustomStructureBase)
+	if i < 0 || i >= len(registry) {
+		return CustomStructure{}, false
+	}
+	return registry[i], true
+}
+
+// config 返回自定义结构对应的 StructureConfig。
+func (cs CustomStructure) config(st StructureType) StructureConfig {
+	return StructureConfig{cs.Salt, cs.Spacing, cs.Spacing - cs.Separation, st, cs.Dim, cs.Frequency}
+}
+
+// getCustomPos 按 RandomSpreadStructurePlacement 计算自定义结构在 region 内的位置，并应用频率削减。
+func getCustomPos(cs CustomStructure, seed uint64, regX, regZ int) (Pos, bool) {
+	w := &WorldgenRandom{}
+	w.SetLargeFeatureWithSalt(seed, regX, regZ, uint64(cs.Salt))
+	n := cs.Spacing - cs.Separation
+	var px, pz int
+	if cs.Spread == SpreadTriangular {
+		px = (w.NextInt(n) + w.NextInt(n)) / 2
+		pz = (w.NextInt(n) + w.NextInt(n)) / 2
+	} else {
+		px = w.NextInt(n)
+		pz = w.NextInt(n)
+	}
+	cx, cz := regX*cs.Spacing+px, regZ*cs.Spacing+pz
+	if !frequencyReductionPass(cs.Reduction, cs.Frequency, seed, cx, cz, cs.Salt) {
+		return Pos{}, false
+	}
+	return Pos{X: cx << 4, Z: cz << 4}, true
+}
+
+// frequencyReductionPass 对应 StructurePlacement.FrequencyReductionMethod，判断 chunk (chunkX, chunkZ) 的尝试是否保留。
+func frequencyReductionPass(method FrequencyReduction, freq float32, seed uint64, chunkX, chunkZ int, salt int32) bool {
+	if freq <= 0 || freq >= 1 {
+		return true
+	}
+	w := &WorldgenRandom{}
+	switch method {
+	case FreqLegacyType1:
+		i, j := int64(chunkX>>4), int64(chunkZ>>4)
+		w.SetSeed(uint64(i^j<<4) ^ seed)
+		w.Next(32) // r.nextInt()，丢弃
+		return w.NextInt(int(1/freq)) == 0
+	case FreqLegacyType2:
+		w.SetLargeFeatureWithSalt(seed, chunkX, chunkZ, 10387320)
+		return w.NextFloat() < freq
+	case FreqLegacyType3:
+		w.SetLargeFeatureSeed(seed, chunkX, chunkZ)
+		return w.NextDouble() < float64(freq)
+	}
+	// 原版 probabilityReducer 调用 setLargeFeatureWithSalt(seed, salt, chunkX, chunkZ)，
+	// 与形参 (seed, regionX, regionZ, salt) 错位：salt 当作 regionX，chunkX 当作 regionZ，chunkZ 当作 salt。
+	// 这里照原版的参数顺序传递，否则结果不一致。
+	w.SetLargeFeatureWithSalt(seed, int(salt), chunkX, uint64(chunkZ))
+	return w.NextFloat() < freq
+}
+
+// structureSetJSON 是数据包 worldgen/structure_set/*.json 中本包使用的字段。
+type structureSetJSON struct {
+	Structures []struct {
+		Structure string `json:"structure"`
+	} `json:"structures"`
+	Placement struct {
+		Type                     string   `json:"type"`
+		Salt                     int32    `json:"salt"`
+		Spacing                  int      `json:"spacing"`
+		Separation               int      `json:"separation"`
+		SpreadType               string   `json:"spread_type"`
+		Frequency                *float32 `json:"frequency"`
+		FrequencyReductionMethod string   `json:"frequency_reduction_method"`
+	} `json:"placement"`
+}
+
+// ParseStructureSet 解析数据包 structure_set JSON，得到名称为 name 的自定义结构（尚未注册）。
+// 只支持 random_spread 放置；structure_set 不包含维度和生物群系，需要由调用者填写 Dim 和 BiomeTags，
+// LoadStructureSets 会从结构定义中读取它们。
+func ParseStructureSet(name string, data []byte) (CustomStructure, error) {
+	var js structureSetJSON
+	if err := json.Unmarshal(data, &js); err != nil {
+		return CustomStructure{}, fmt.Errorf("structure set %q: %w", name, err)
+	}
+	p := js.Placement
+	if strings.TrimPrefix(p.Type, "minecraft:") != "random_spread" {
+		return CustomStructure{}, fmt.Errorf("structure set %q: %w %q", name, ErrUnsupportedPlacement, p.Type)
+	}
+	cs := CustomStructure{
+		Name:       name,
+		Salt:       p.Salt,
+		Spacing:    p.Spacing,
+		Separation: p.Separation,
+		Frequency:  1,
+	}
+	switch p.SpreadType {
+	case "", "linear":
+		cs.Spread = SpreadLinear
+	case "triangular":
+		cs.Spread = SpreadTriangular
+	default:
+		return CustomStructure{}, fmt.Errorf("structure set %q: unknown spread type %q", name, p.SpreadType)
+	}
+	if p.Frequency != nil {
+		cs.Frequency = *p.Frequency
+	}
+	switch p.FrequencyReductionMethod {
+	case "", "default":
+		cs.Reduction = FreqDefault
+	case "legacy_type_1":
+		cs.Reduction = FreqLegacyType1
+	case "legacy_type_2":
+		cs.Reduction = FreqLegacyType2
+	case "legacy_type_3":
+		cs.Reduction = FreqLegacyType3
+	default:
+		return CustomStructure{}, fmt.Errorf("structure set %q: unknown frequency reduction method %q", name, p.FrequencyReductionMethod)
+	}
+	return cs, nil
+}
+
+// walkData 对 fsys 中每个 data/<namespace>/<dir> 下（含子目录）的 JSON 文件调用 fn，
+// 资源名为 <namespace>:<相对路径>。
+func walkData(fsys fs.FS, dir string, fn func(name string, data []byte) error) error {
+	namespaces, err := fs.Glob(fsys, "data/*")
+	if err != nil {
+		return err
+	}
+	for _, nsDir := range namespaces {
+		root := path.Join(nsDir, dir)
+		if _, err := fs.Stat(fsys, root); err != nil {
+			continue
+		}
+		err := fs.WalkDir(fsys, root, func(p string, e fs.DirEntry, err error) error {
+			if err != nil || e.IsDir() || path.Ext(p) != ".json" {
+				return err
+			}
+			data, err := fs.ReadFile(fsys, p)
+			if err != nil {
+				return err
+			}
+			name := path.Base(nsDir) + ":" + strings.TrimSuffix(strings.TrimPrefix(p, root+"/"), ".json")
+			return fn(name, data)
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadBiomeTags 读取 fsys 下所有 data/<namespace>/tags/worldgen/biome 中的生物群系标签，供 RegisterStructure 解析 BiomeTags。
+// fsys 可以是数据包、模组或游戏 jar（archive/zip.Reader 实现了 fs.FS）；同名标签按原版规则合并，replace 为 true 时覆盖。
+func LoadBiomeTags(fsys fs.FS) error {
+	tags := map[string][]biomeTagEntry{}
+	replace := map[string]bool{}
+	err := walkData(fsys, "tags/worldgen/biome", func(name string, data []byte) error {
+		var js struct {
+			Replace bool              `json:"replace"`
+			Values  []json.RawMessage `json:"values"`
+		}
+		if err := json.Unmarshal(data, &js); err != nil {
+			return fmt.Errorf("biome tag %q: %w", name, err)
+		}
+		var entries []biomeTagEntry
+		for _, v := range js.Values {
+			var e struct {
+				ID       string `json:"id"`
+				Required *bool  `json:"required"`
+			}
+			if err := json.Unmarshal(v, &e.ID); err != nil {
+				if err := json.Unmarshal(v, &e); err != nil {
+					return fmt.Errorf("biome tag %q: %w", name, err)
+				}
+			}
+			entries = append(entries, biomeTagEntry{e.ID, e.Required != nil && !*e.Required})
+		}
+		tags[name] = entries
+		replace[name] = js.Replace
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for name, entries := range tags {
+		if replace[name] {
+			biomeTags[name] = nil
+		}
+		for _, e := range entries {
+			if !slices.Contains(biomeTags[name], e) {
+				biomeTags[name] = append(biomeTags[name], e)
+			}
+		}
+	}
+	return nil
+}
+
+// loadStructureBiomes 读取 fsys 中结构定义的 biomes 字段（生物群系 ID、标签或 ID 列表），写入 out。
+// 1.18.2 的结构定义位于 worldgen/configured_structure_feature，1.19+ 位于 worldgen/structure。
+func loadStructureBiomes(fsys fs.FS, out map[string][]string) error {
+	for _, dir := range []string{"worldgen/configured_structure_feature", "worldgen/structure"} {
+		err := walkData(fsys, dir, func(name string, data []byte) error {
+			var js struct {
+				Biomes json.RawMessage `json:"biomes"`
+			}
+			if err := json.Unmarshal(data, &js); err != nil {
+				return fmt.Errorf("structure %q: %w", name, err)
+			}
+			var one string
+			if err := json.Unmarshal(js.Biomes, &one); err == nil {
+				out[name] = []string{one}
+				return nil
+			}
+			var list []string
+			if err := json.Unmarshal(js.Biomes, &list); err != nil {
+				return fmt.Errorf("structure %q: biomes: %w", name, err)
+			}
+			out[name] = list
+			return nil
+		})
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// LoadStructureSets 读取数据包根目录 fsys 下所有 data/<namespace>/worldgen/structure_set 中（含子目录）的结构集，
+// 注册其中的 random_spread 结构集，返回名称（<namespace>:<路径>）到结构类型的映射。重复加载时按名称替换原有定义。
+//
+// 结构集的生物群系取自其中各结构定义的 biomes，维度由这些生物群系确定。生物群系标签和结构定义先在 deps 中、
+// 再在 fsys 中查找：数据包引用原版标签（例如 "#minecraft:is_overworld"）或原版结构时，需要把游戏 jar 作为 deps 传入。
+// 其他放置类型（例如要塞的 concentric_rings）以及只在本包不会生成的生物群系中生成的结构集会被跳过。
+func LoadStructureSets(fsys fs.FS, deps ...fs.FS) (map[string]StructureType, error) {
+	structures := map[string][]string{}
+	for _, d := range append(deps, fsys) {
+		if err := LoadBiomeTags(d); err != nil {
+			return nil, err
+		}
+		if err := loadStructureBiomes(d, structures); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make(map[string]StructureType)
+	err := walkData(fsys, "worldgen/structure_set", func(name string, data []byte) error {
+		cs, err := ParseStructureSet(name, data)
+		if errors.Is(err, ErrUnsupportedPlacement) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		var js structureSetJSON
+		if err := json.Unmarshal(data, &js); err != nil {
+			return fmt.Errorf("structure set %q: %w", name, err)
+		}
+		for _, e := range js.Structures {
+			biomes, ok := structures[resourceKey(e.Structure)]
+			if !ok {
+				return fmt.Errorf("structure set %q: structure %q not found", name, e.Structure)
+			}
+			cs.BiomeTags = append(cs.BiomeTags, biomes...)
+		}
+
+		registryMu.RLock()
+		biomes, err := resolveBiomeTags(cs.BiomeTags)
+		registryMu.RUnlock()
+		if errors.Is(err, ErrNoKnownBiomes) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("structure set %q: %w", name, err)
+		}
+		for i, b := range biomes {
+			if i == 0 {
+				cs.Dim = biomeDimension(b)
+			} else if biomeDimension(b) != cs.Dim {
+				return fmt.Errorf("structure set %q: biomes span several dimensions", name)
+			}
+		}
+		st, err := RegisterStructure(cs)
+		if err != nil {
+			return err
+		}
+		out[name] = st
+		return nil
+	})
+	return out, err
+}
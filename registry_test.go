@@ -0,0 +1,28 @@
+package gobiomes
+
+import "testing"
+
+func TestFrequencyReductionDefault(t *testing.T) {
+	const seed, salt, freq = 12345, 14357620, 0.2
+	pass, flips := 0, 0
+	for cx := -8; cx <= 8; cx++ {
+		for cz := -8; cz <= 8; cz++ {
+			// 原版把 salt 当作 regionX、chunkX 当作 regionZ、chunkZ 当作 salt
+			want := NewRng(uint64(salt)*341873128712+uint64(cx)*132897987541+seed+uint64(cz)).NextFloat() < freq
+			swapped := NewRng(uint64(cx)*341873128712+uint64(cz)*132897987541+seed+salt).NextFloat() < freq
+			got := frequencyReductionPass(FreqDefault, freq, seed, cx, cz, salt)
+			if got != want {
+				t.Errorf("chunk (%d, %d): pass %v, want %v", cx, cz, got, want)
+			}
+			if got {
+				pass++
+			}
+			if want != swapped {
+				flips++
+			}
+		}
+	}
+	if pass == 0 || flips == 0 {
+		t.Errorf("degenerate sample: %d passes, %d differences from the declared argument order", pass, flips)
+	}
+}
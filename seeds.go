@@ -0,0 +1,166 @@
+package gobiomes
+
+// 1.18+ 的装饰 step（GenerationStep.Decoration）序号。1.16 加入了 LAKES 和 STRONGHOLDS，1.18 加入了 FLUID_SPRINGS。
+const (
+	StepRawGeneration = iota
+	StepLakes
+	StepLocalModifications
+	StepUndergroundStructures
+	StepSurfaceStructures
+	StepStrongholds
+	StepUndergroundOres
+	StepUndergroundDecoration
+	StepFluidSprings
+	StepVegetalDecoration
+	StepTopLayerModification
+)
+
+// DecorationStep 将 step 常量转换为该版本实际使用的序号。
+// 旧版本中不存在的 step 映射到当时放置对应特征的 step（例如 1.18 之前的泉水属于 VEGETAL_DECORATION）。
+func DecorationStep(mc int, step int) int {
+	switch {
+	case mc <= MC_1_15:
+		// RAW_GENERATION, LOCAL_MODIFICATIONS, UNDERGROUND_STRUCTURES, SURFACE_STRUCTURES,
+		// UNDERGROUND_ORES, UNDERGROUND_DECORATION, VEGETAL_DECORATION, TOP_LAYER_MODIFICATION
+		return [...]int{0, 1, 1, 2, 3, 2, 4, 5, 6, 6, 7}[step]
+	case mc <= MC_1_17:
+		return [...]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 8, 9}[step]
+	}
+	return step
+}
+
+// WorldgenRandom 对应原版的 WorldgenRandom：1.18 之前（以及 1.18+ 的结构和洞穴）基于 Java Random，
+// 1.18+ 的装饰阶段基于 Xoroshiro128++，但 nextInt/nextLong 等仍按 java.util.Random 的方式由 next(bits) 组合。
+type WorldgenRandom struct {
+	xoroshiro bool
+	r         Rng
+	xr        Xoroshiro128
+}
+
+// NewLegacyRandom 创建基于 Java Random 的 WorldgenRandom。
+func NewLegacyRandom(seed uint64) *WorldgenRandom {
+	w := &WorldgenRandom{}
+	w.SetSeed(seed)
+	return w
+}
+
+// NewDecorationRandom 创建版本 mc 的装饰阶段 WorldgenRandom（1.18+ 使用 Xoroshiro128++）。
+func NewDecorationRandom(mc int) *WorldgenRandom {
+	return &WorldgenRandom{xoroshiro: mc >= MC_1_18}
+}
+
+// SetSeed 设置种子。
+func (w *WorldgenRandom) SetSeed(seed uint64) {
+	if w.xoroshiro {
+		w.xr.SetSeed(seed)
+	} else {
+		w.r.SetSeed(seed)
+	}
+}
+
+// Next 返回指定位数的随机位。
+func (w *WorldgenRandom) Next(bits int) int32 {
+	if w.xoroshiro {
+		return int32(w.xr.NextLong() >> (64 - bits))
+	}
+	return w.r.Next(bits)
+}
+
+// NextInt 返回 [0, n) 之间的随机整数。
+func (w *WorldgenRandom) NextInt(n int) int {
+	if w.xoroshiro {
+		return w.xr.NextIntJ(uint32(n))
+	}
+	return w.r.NextInt(n)
+}
+
+// NextLong 返回随机 int64。
+func (w *WorldgenRandom) NextLong() int64 {
+	if w.xoroshiro {
+		return w.xr.NextLongJ()
+	}
+	return w.r.NextLong()
+}
+
+// NextFloat 返回 [0.0, 1.0) 之间的随机 float32。
+func (w *WorldgenRandom) NextFloat() float32 {
+	return float32(w.Next(24)) / float32(1<<24)
+}
+
+// NextDouble 返回 [0.0, 1.0) 之间的随机 float64。
+func (w *WorldgenRandom) NextDouble() float64 {
+	return float64((int64(w.Next(26))<<27)+int64(w.Next(27))) / float64(int64(1)<<53)
+}
+
+// SetLargeFeatureSeed 对应 setLargeFeatureSeed，用于结构起点和洞穴。
+func (w *WorldgenRandom) SetLargeFeatureSeed(seed uint64, chunkX, chunkZ int) {
+	w.SetSeed(seed)
+	a := uint64(w.NextLong())
+	b := uint64(w.NextLong())
+	w.SetSeed(uint64(chunkX)*a ^ uint64(chunkZ)*b ^ seed)
+}
+
+// SetLargeFeatureWithSalt 对应 setLargeFeatureWithSalt，用于结构在 region 内的位置。
+func (w *WorldgenRandom) SetLargeFeatureWithSalt(seed uint64, regX, regZ int, salt uint64) {
+	w.SetSeed(uint64(regX)*341873128712 + uint64(regZ)*132897987541 + seed + salt)
+}
+
+// SetDecorationSeed 对应 setDecorationSeed（1.13+），x、z 为 chunk 最小角的 block 坐标，返回装饰种子。
+func (w *WorldgenRandom) SetDecorationSeed(seed uint64, x, z int) uint64 {
+	w.SetSeed(seed)
+	a := uint64(w.NextLong()) | 1
+	b := uint64(w.NextLong()) | 1
+	s := uint64(x)*a + uint64(z)*b ^ seed
+	w.SetSeed(s)
+	return s
+}
+
+// SetFeatureSeed 对应 setFeatureSeed：装饰种子加上特征在 step 内的序号。
+func (w *WorldgenRandom) SetFeatureSeed(decorationSeed uint64, index, step int) {
+	w.SetSeed(decorationSeed + uint64(index) + 10000*uint64(step))
+}
+
+// SetLegacyPopulationSeed 对应 1.13 之前 ChunkProviderOverworld.populate 中的随机数初始化。
+func (w *WorldgenRandom) SetLegacyPopulationSeed(seed uint64, chunkX, chunkZ int) uint64 {
+	w.SetSeed(seed)
+	a := uint64(w.NextLong()/2*2 + 1)
+	b := uint64(w.NextLong()/2*2 + 1)
+	s := uint64(chunkX)*a + uint64(chunkZ)*b ^ seed
+	w.SetSeed(s)
+	return s
+}
+
+// SetCarverSeed 对应洞穴雕刻器的种子：第 index 个雕刻器在 chunk (chunkX, chunkZ) 处的随机数。
+// 1.13 之前只有单个雕刻器，index 为 0 时与 MapGenBase 的种子一致。
+func (w *WorldgenRandom) SetCarverSeed(seed uint64, index, chunkX, chunkZ int) {
+	w.SetLargeFeatureSeed(seed+uint64(index), chunkX, chunkZ)
+}
+
+// PopulationSeed 返回 chunk 的装饰（population）种子：1.13 之前为 populate 种子，1.13+ 为 setDecorationSeed 的结果。
+func (f *Finder) PopulationSeed(seed uint64, chunkX, chunkZ int) uint64 {
+	w := NewDecorationRandom(f.Version)
+	if f.Version <= MC_1_12 {
+		return w.SetLegacyPopulationSeed(seed, chunkX, chunkZ)
+	}
+	return w.SetDecorationSeed(seed, chunkX*16, chunkZ*16)
+}
+
+// FeatureRandom 返回 1.13+ chunk 内 step 中第 index 个特征使用的随机数，step 为 Step* 常量。
+// 1.13 之前所有特征共用 populate 随机数，此时返回 populate 随机数。
+func (f *Finder) FeatureRandom(seed uint64, chunkX, chunkZ, index, step int) *WorldgenRandom {
+	w := NewDecorationRandom(f.Version)
+	if f.Version <= MC_1_12 {
+		w.SetLegacyPopulationSeed(seed, chunkX, chunkZ)
+		return w
+	}
+	s := w.SetDecorationSeed(seed, chunkX*16, chunkZ*16)
+	w.SetFeatureSeed(s, index, DecorationStep(f.Version, step))
+	return w
+}
+
+// CarverRandom 返回 chunk 内第 index 个洞穴雕刻器使用的随机数（洞穴始终使用 Java Random）。
+func (f *Finder) CarverRandom(seed uint64, index, chunkX, chunkZ int) *WorldgenRandom {
+	w := &WorldgenRandom{}
+	w.SetCarverSeed(seed, index, chunkX, chunkZ)
+	return w
+}
@@ -0,0 +1,178 @@
+package gobiomes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// seedSearchBlock 是分配给单个 worker 的低 48 位连续区间大小。
+const seedSearchBlock = 1 << 16
+
+// StructurePredicate 在结构种子（低 48 位）上判断结构位置条件。
+type StructurePredicate func(f *Finder, seed48 uint64) bool
+
+// BiomePredicate 在完整世界种子上判断生物群系条件，gen 已经以该种子 ApplySeed 到主世界。
+type BiomePredicate func(gen *Generator) bool
+
+// SeedSearch 描述两阶段种子搜索：先在低 48 位上检查所有 Structure 条件，
+// 通过后枚举高 16 位得到完整种子，再用 Biome 条件筛选。
+type SeedSearch struct {
+	Version int
+	Flags   uint32 // 传给 NewGenerator，例如 LARGE_BIOMES
+
+	Structure []StructurePredicate
+	Biome     []BiomePredicate
+
+	// Start, End 是搜索的低 48 位区间 [Start, End)；End 为 0 时表示搜索到 2^48。
+	Start, End uint64
+	Workers    int
+}
+
+// StructureIn 返回结构 st 在 region (regX, regZ) 的生成尝试位置落在 area 内的条件。
+// 1.18+ 的沙漠水井和紫水晶晶洞由完整 64 位种子决定，不能在结构种子上判断，此时条件永远不满足，
+// 应改用 StructureInWorld。
+func StructureIn(st StructureType, regX, regZ int, area SearchArea) StructurePredicate {
+	return func(f *Finder, seed48 uint64) bool {
+		if needsWorldSeed(f.Version, st) {
+			return false
+		}
+		p, err := f.GetStructurePos(st, seed48, regX, regZ)
+		return err == nil && p != nil && area.Contains(p.X, p.Z)
+	}
+}
+
+// StructureInWorld 与 StructureIn 相同，但在完整世界种子上判断，可用于任何结构。
+func StructureInWorld(st StructureType, regX, regZ int, area SearchArea) BiomePredicate {
+	return func(gen *Generator) bool {
+		p, err := NewFinder(gen.Version).GetStructurePos(st, gen.Seed, regX, regZ)
+		return err == nil && p != nil && area.Contains(p.X, p.Z)
+	}
+}
+
+// needsWorldSeed 判断结构位置是否依赖世界种子的高 16 位：1.18+ 的装饰种子使用 Xoroshiro。
+func needsWorldSeed(mc int, st StructureType) bool {
+	return mc >= MC_1_18 && (st == DesertWell || st == Geode)
+}
+
+// StructureViable 返回结构 st 在 region (regX, regZ) 的生成尝试位置可以生成（生物群系满足）的条件。
+func StructureViable(st StructureType, regX, regZ int) BiomePredicate {
+	return func(gen *Generator) bool {
+		p, err := gen.GetStructurePos(st, regX, regZ)
+		return err == nil && p != nil
+	}
+}
+
+// BiomeAt 返回 block 坐标 (x, z) 处（y = 63）的生物群系为 biomes 之一的条件。
+func BiomeAt(x, z int, biomes ...Biome) BiomePredicate {
+	return func(gen *Generator) bool {
+		b := gen.GetBiomeAt(4, x>>2, 63>>2, z>>2)
+		for _, want := range biomes {
+			if b == want {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+// Run 启动搜索，匹配的 64 位世界种子按找到的顺序（不保证递增）写入返回的 channel，
+// 搜索结束或 ctx 被取消时关闭。低 48 位区间按 2^16 大小分块交给 Workers 个 worker，
+// 每个 worker 使用自己的 Generator。没有 Biome 条件时只输出高 16 位为 0 的种子，
+// 即结构种子本身。
+func (s *SeedSearch) Run(ctx context.Context) (<-chan uint64, error) {
+	end := s.End
+	if end == 0 || end > 1<<48 {
+		end = 1 << 48
+	}
+	if s.Start >= end {
+		return nil, fmt.Errorf("empty seed range [%d, %d)", s.Start, end)
+	}
+	workers := max(s.Workers, 1)
+
+	out := make(chan uint64, 64)
+	blocks := make(chan uint64, workers)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.worker(ctx, blocks, end, out)
+		}()
+	}
+	go func() {
+		defer close(blocks)
+		for b := s.Start; b < end; b += seedSearchBlock {
+			select {
+			case blocks <- b:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out, nil
+}
+
+func (s *SeedSearch) worker(ctx context.Context, blocks <-chan uint64, end uint64, out chan<- uint64) {
+	f := NewFinder(s.Version)
+	var gen *Generator
+	if len(s.Biome) > 0 {
+		gen = NewGenerator(s.Version, s.Flags)
+	}
+	emit := func(seed uint64) bool {
+		select {
+		case out <- seed:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	for b := range blocks {
+		if ctx.Err() != nil {
+			return
+		}
+		for lower := b; lower < min(b+seedSearchBlock, end); lower++ {
+			if !s.matchStructure(f, lower) {
+				continue
+			}
+			if gen == nil {
+				if !emit(lower) {
+					return
+				}
+				continue
+			}
+			for upper := uint64(0); upper < 1<<16; upper++ {
+				seed := lower | upper<<48
+				gen.ApplySeed(seed, DimOverworld)
+				if s.matchBiome(gen) && !emit(seed) {
+					return
+				}
+			}
+			if ctx.Err() != nil {
+				return
+			}
+		}
+	}
+}
+
+func (s *SeedSearch) matchStructure(f *Finder, seed48 uint64) bool {
+	for _, p := range s.Structure {
+		if !p(f, seed48) {
+			return false
+		}
+	}
+	return true
+}
+
+func (s *SeedSearch) matchBiome(gen *Generator) bool {
+	for _, p := range s.Biome {
+		if !p(gen) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,150 @@
+package gobiomes
+
+import "math"
+
+// 挂机点周围史莱姆可以生成的水平距离范围：24 格内不生成，128 格外立即消失。
+const (
+	slimeAFKMin = 24
+	slimeAFKMax = 128
+)
+
+// IsSlimeChunk 判断 chunk (chunkX, chunkZ) 是否为史莱姆区块。
+func IsSlimeChunk(seed uint64, chunkX, chunkZ int) bool {
+	x, z := int32(chunkX), int32(chunkZ)
+	s := seed +
+		uint64(int64(x*x*0x4c1906)) +
+		uint64(int64(x*0x5ac0db)) +
+		uint64(int64(z*z))*0x4307a7 +
+		uint64(int64(z*0x5f24f))
+	r := NewRng(s ^ 0x3ad8025f)
+	return r.NextInt(10) == 0
+}
+
+// slimeChunkMaxY 是史莱姆区块中史莱姆可以生成的最高 Y（不含）。
+const slimeChunkMaxY = 40
+
+// SlimeCluster 描述一个挂机点及其覆盖的史莱姆区块。
+type SlimeCluster struct {
+	Center    Pos   // 挂机点（block 坐标，玩家站在该方块上）
+	Y         int   // 挂机点高度（玩家脚部所在的 Y）
+	Spawnable int   // 史莱姆区块中与挂机点距离在 (24, 128] 内、Y 低于 40 的方块位置数
+	Chunks    []Pos // 含有这些位置的史莱姆区块（chunk 坐标）
+}
+
+// FindSlimeAFK 在 chunk 范围 [chunkX0, chunkX1] x [chunkZ0, chunkZ1] 内寻找高度为 y 的最佳挂机点。
+// 逐方块搜索，以史莱姆区块中可生成史莱姆的方块位置数衡量：位置在世界底部与 y=40 之间，
+// 到挂机点的三维距离大于 24 且不超过 128，与原版 NaturalSpawner 和 Mob.checkDespawn 的判定一致。
+// 不考虑方块是否真的可供生成（需要地形），得分相同时取先遍历到的点（Z 较小、其次 X 较小）。
+func FindSlimeAFK(mc int, seed uint64, chunkX0, chunkZ0, chunkX1, chunkZ1, y int) SlimeCluster {
+	if chunkX0 > chunkX1 {
+		chunkX0, chunkX1 = chunkX1, chunkX0
+	}
+	if chunkZ0 > chunkZ1 {
+		chunkZ0, chunkZ1 = chunkZ1, chunkZ0
+	}
+	// 预先计算扩展范围内的史莱姆区块
+	const pad = slimeAFKMax/16 + 1
+	w := chunkX1 - chunkX0 + 1 + 2*pad
+	h := chunkZ1 - chunkZ0 + 1 + 2*pad
+	slime := make([]bool, w*h)
+	for j := 0; j < h; j++ {
+		for i := 0; i < w; i++ {
+			slime[j*w+i] = IsSlimeChunk(seed, chunkX0-pad+i, chunkZ0-pad+j)
+		}
+	}
+	minY, _ := dimHeightRange(mc, DimOverworld)
+	k := newSlimeKernel(minY-y, slimeChunkMaxY-1-y)
+
+	// score 返回挂机点 (x, z) 的得分，visit 非空时对每个有贡献的史莱姆区块调用
+	score := func(x, z int, visit func(cx, cz int)) int {
+		n := 0
+		for cz := (z - slimeAFKMax) >> 4; cz <= (z+slimeAFKMax)>>4; cz++ {
+			for cx := (x - slimeAFKMax) >> 4; cx <= (x+slimeAFKMax)>>4; cx++ {
+				if !slime[(cz-chunkZ0+pad)*w+cx-chunkX0+pad] {
+					continue
+				}
+				m := k.sum(cx*16-x, cz*16-z, cx*16+15-x, cz*16+15-z)
+				if m > 0 && visit != nil {
+					visit(cx, cz)
+				}
+				n += m
+			}
+		}
+		return n
+	}
+
+	best := SlimeCluster{Y: y, Spawnable: -1}
+	for bz := chunkZ0 * 16; bz <= chunkZ1*16+15; bz++ {
+		for bx := chunkX0 * 16; bx <= chunkX1*16+15; bx++ {
+			if n := score(bx, bz, nil); n > best.Spawnable {
+				best.Spawnable = n
+				best.Center = Pos{bx, bz}
+			}
+		}
+	}
+	score(best.Center.X, best.Center.Z, func(cx, cz int) {
+		best.Chunks = append(best.Chunks, Pos{cx, cz})
+	})
+	return best
+}
+
+// slimeKernel 是挂机点周围每个水平偏移 (dx, dz) 上可生成位置数的二维前缀和，偏移范围为 [-128, 128]。
+type slimeKernel struct {
+	s [2*slimeAFKMax + 2][2*slimeAFKMax + 2]int
+}
+
+// newSlimeKernel 计算竖直偏移限定在 [dyLo, dyHi] 内时的核。
+func newSlimeKernel(dyLo, dyHi int) *slimeKernel {
+	k := &slimeKernel{}
+	const n = 2*slimeAFKMax + 1
+	for j := 0; j < n; j++ {
+		for i := 0; i < n; i++ {
+			dx, dz := i-slimeAFKMax, j-slimeAFKMax
+			c := slimeColumnCount(dx*dx+dz*dz, dyLo, dyHi)
+			k.s[j+1][i+1] = c + k.s[j][i+1] + k.s[j+1][i] - k.s[j][i]
+		}
+	}
+	return k
+}
+
+// sum 返回偏移矩形 [dx0, dx1] x [dz0, dz1] 内的可生成位置数。
+func (k *slimeKernel) sum(dx0, dz0, dx1, dz1 int) int {
+	dx0, dz0 = max(dx0, -slimeAFKMax)+slimeAFKMax, max(dz0, -slimeAFKMax)+slimeAFKMax
+	dx1, dz1 = min(dx1, slimeAFKMax)+slimeAFKMax, min(dz1, slimeAFKMax)+slimeAFKMax
+	if dx0 > dx1 || dz0 > dz1 {
+		return 0
+	}
+	return k.s[dz1+1][dx1+1] - k.s[dz0][dx1+1] - k.s[dz1+1][dx0] + k.s[dz0][dx0]
+}
+
+// slimeColumnCount 返回水平距离平方为 h2 的方块列中，竖直偏移 dy 在 [dyLo, dyHi] 内且
+// 24² < h2 + dy² <= 128² 的位置数。
+func slimeColumnCount(h2, dyLo, dyHi int) int {
+	if h2 > slimeAFKMax*slimeAFKMax {
+		return 0
+	}
+	a := isqrt(slimeAFKMax*slimeAFKMax - h2)
+	n := overlap(dyLo, dyHi, -a, a)
+	if h2 <= slimeAFKMin*slimeAFKMin {
+		b := isqrt(slimeAFKMin*slimeAFKMin - h2)
+		n -= overlap(dyLo, dyHi, -b, b)
+	}
+	return n
+}
+
+// overlap 返回整数区间 [a0, a1] 与 [b0, b1] 交集的长度。
+func overlap(a0, a1, b0, b1 int) int {
+	return max(0, min(a1, b1)-max(a0, b0)+1)
+}
+
+// isqrt 返回不超过 sqrt(n) 的最大整数。
+func isqrt(n int) int {
+	r := int(math.Sqrt(float64(n)))
+	for r*r > n {
+		r--
+	}
+	for (r+1)*(r+1) <= n {
+		r++
+	}
+	return r
+}
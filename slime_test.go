@@ -0,0 +1,31 @@
+package gobiomes
+
+import "testing"
+
+func TestIsSlimeChunk(t *testing.T) {
+	// 期望值由独立的 java.util.Random 实现（按 Java 的 int 溢出规则）计算
+	tests := []struct {
+		seed   uint64
+		chunkX int
+		chunkZ int
+		want   bool
+	}{
+		{12345, 0, 0, false},
+		{12345, 1, 0, false},
+		{12345, -3, 7, true},
+		{12345, -12, 1, true},
+		{12345, 100, -100, false},
+		{12345, 65536, -65536, true},
+		{12345, 1870000, -1870036, true},
+		{12345, 1875000, 1875000, false},
+		{2483313382402348964, -12, 5, true},
+		{2483313382402348964, -1, -1, false},
+		{2483313382402348964, 1870001, -1870034, true},
+		{2483313382402348964, 1870000, -1870001, false},
+	}
+	for _, tt := range tests {
+		if got := IsSlimeChunk(tt.seed, tt.chunkX, tt.chunkZ); got != tt.want {
+			t.Errorf("seed %d chunk (%d, %d): %v, want %v", tt.seed, tt.chunkX, tt.chunkZ, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,463 @@
+package gobiomes
+
+import (
+	"fmt"
+)
+
+// 要塞部件类型，对应 StrongholdPieces 中的各部件类。
+const (
+	StrongholdStart              = iota // 起始螺旋楼梯 (StartPiece)
+	StrongholdStraight                  // 直走廊
+	StrongholdPrisonHall                // 监狱
+	StrongholdLeftTurn                  // 左转
+	StrongholdRightTurn                 // 右转
+	StrongholdRoomCrossing              // 十字房间
+	StrongholdStraightStairsDown        // 直楼梯
+	StrongholdStairsDown                // 螺旋楼梯
+	StrongholdFiveCrossing              // 五岔路口
+	StrongholdChestCorridor             // 箱子走廊
+	StrongholdLibrary                   // 图书馆
+	StrongholdPortalRoom                // 末地传送门房间
+	StrongholdFillerCorridor            // 填充走廊
+)
+
+// strongholdPieceInfo 记录部件名称和 BoundingBox.orientBox 使用的偏移与尺寸。
+var strongholdPieceInfo = [...]struct {
+	name                 string
+	offX, offY, offZ     int
+	width, height, depth int
+}{
+	StrongholdStart:              {"stronghold_start", 0, 0, 0, 5, 11, 5},
+	StrongholdStraight:           {"straight", -1, -1, 0, 5, 5, 7},
+	StrongholdPrisonHall:         {"prison_hall", -1, -1, 0, 9, 5, 11},
+	StrongholdLeftTurn:           {"left_turn", -1, -1, 0, 5, 5, 5},
+	StrongholdRightTurn:          {"right_turn", -1, -1, 0, 5, 5, 5},
+	StrongholdRoomCrossing:       {"room_crossing", -4, -1, 0, 11, 7, 11},
+	StrongholdStraightStairsDown: {"straight_stairs_down", -1, -7, 0, 5, 11, 8},
+	StrongholdStairsDown:         {"stairs_down", -1, -7, 0, 5, 11, 5},
+	StrongholdFiveCrossing:       {"five_crossing", -4, -3, 0, 10, 9, 11},
+	StrongholdChestCorridor:      {"chest_corridor", -1, -1, 0, 5, 5, 7},
+	StrongholdLibrary:            {"library", -4, -1, 0, 14, 11, 15},
+	StrongholdPortalRoom:         {"portal_room", -4, -1, 0, 11, 8, 16},
+	StrongholdFillerCorridor:     {"filler_corridor", -1, -1, 0, 5, 5, 0},
+}
+
+// strongholdWeight 对应 StrongholdPieces.PieceWeight。
+type strongholdWeight struct {
+	typ      int
+	weight   int
+	maxCount int
+	placed   int
+}
+
+func (w *strongholdWeight) isValid() bool {
+	return w.maxCount == 0 || w.placed < w.maxCount
+}
+
+// doPlace 对应 PieceWeight.doPlace，图书馆和传送门房间有最小深度限制。
+func (w *strongholdWeight) doPlace(depth int) bool {
+	switch w.typ {
+	case StrongholdLibrary:
+		return w.isValid() && depth > 4
+	case StrongholdPortalRoom:
+		return w.isValid() && depth > 5
+	}
+	return w.isValid()
+}
+
+var strongholdWeights = []strongholdWeight{
+	{StrongholdStraight, 40, 0, 0},
+	{StrongholdPrisonHall, 5, 5, 0},
+	{StrongholdLeftTurn, 20, 0, 0},
+	{StrongholdRightTurn, 20, 0, 0},
+	{StrongholdRoomCrossing, 10, 6, 0},
+	{StrongholdStraightStairsDown, 5, 5, 0},
+	{StrongholdStairsDown, 5, 5, 0},
+	{StrongholdFiveCrossing, 5, 4, 0},
+	{StrongholdChestCorridor, 5, 4, 0},
+	{StrongholdLibrary, 10, 2, 0},
+	{StrongholdPortalRoom, 20, 1, 0},
+}
+
+// 部件构造时由随机数决定的出口，存放在 strongholdGen.exits 中。
+const (
+	exitLeft      = 1 << iota // 直走廊左侧 / 五岔路口左下
+	exitRight                 // 直走廊右侧 / 五岔路口右下
+	exitLeftHigh              // 五岔路口左上
+	exitRightHigh             // 五岔路口右上
+)
+
+// strongholdGen 保存要塞部件生成过程中的状态，对应 StrongholdPieces 的静态状态和 StartPiece。
+type strongholdGen struct {
+	r       *Rng
+	pieces  []Piece
+	exits   []uint8
+	pending []int
+	weights []strongholdWeight
+	total   int
+	prev    int
+	imposed int
+	portal  int
+	startBB BoundingBox
+}
+
+// updatePieceWeight 对应 StrongholdPieces.updatePieceWeight。
+func (g *strongholdGen) updatePieceWeight() bool {
+	limited := false
+	g.total = 0
+	for _, w := range g.weights {
+		if w.maxCount > 0 && w.placed < w.maxCount {
+			limited = true
+		}
+		g.total += w.weight
+	}
+	return limited
+}
+
+// createPiece 对应各部件的 createPiece：包围盒合法且不与已有部件相交时创建部件，并执行构造函数中的随机数调用。
+func (g *strongholdGen) createPiece(typ, x, y, z, dir, depth int) (*Piece, uint8) {
+	info := strongholdPieceInfo[typ]
+	bb := orientBox(x, y, z, info.offX, info.offY, info.offZ, info.width, info.height, info.depth, dir)
+	ok := func(bb BoundingBox) bool {
+		return bb.MinY > 10 && findCollisionPiece(g.pieces, bb) < 0
+	}
+	if !ok(bb) {
+		if typ != StrongholdLibrary {
+			return nil, 0
+		}
+		// 放不下高图书馆时尝试矮图书馆
+		bb = orientBox(x, y, z, info.offX, info.offY, info.offZ, info.width, 6, info.depth, dir)
+		if !ok(bb) {
+			return nil, 0
+		}
+	}
+
+	var exits uint8
+	if typ != StrongholdPortalRoom {
+		g.r.NextInt(5) // randomSmallDoor
+	}
+	switch typ {
+	case StrongholdStraight:
+		if g.r.NextInt(2) == 0 {
+			exits |= exitLeft
+		}
+		if g.r.NextInt(2) == 0 {
+			exits |= exitRight
+		}
+	case StrongholdRoomCrossing:
+		g.r.NextInt(5) // 房间类型
+	case StrongholdFiveCrossing:
+		if g.r.Next(1) != 0 {
+			exits |= exitLeft
+		}
+		if g.r.Next(1) != 0 {
+			exits |= exitLeftHigh
+		}
+		if g.r.Next(1) != 0 {
+			exits |= exitRight
+		}
+		if g.r.NextInt(3) > 0 {
+			exits |= exitRightHigh
+		}
+	}
+	return &Piece{Name: info.name, Type: typ, Pos: Pos3{x, y, z}, BB: bb, Rotation: dir, Depth: depth}, exits
+}
+
+// fillerBox 对应 FillerCorridor.findPieceBox：当前方被同高度的部件挡住时，返回能填补空隙的最长走廊。
+func (g *strongholdGen) fillerBox(x, y, z, dir int) (BoundingBox, bool) {
+	bb := orientBox(x, y, z, -1, -1, 0, 5, 5, 4, dir)
+	i := findCollisionPiece(g.pieces, bb)
+	if i < 0 || g.pieces[i].BB.MinY != bb.MinY {
+		return BoundingBox{}, false
+	}
+	for d := 3; d >= 1; d-- {
+		bb = orientBox(x, y, z, -1, -1, 0, 5, 5, d-1, dir)
+		if !g.pieces[i].BB.Intersects(bb) {
+			return orientBox(x, y, z, -1, -1, 0, 5, 5, d, dir), true
+		}
+	}
+	return BoundingBox{}, false
+}
+
+// generatePiece 对应 StrongholdPieces.generatePieceFromSmallDoor。
+func (g *strongholdGen) generatePiece(x, y, z, dir, depth int) (*Piece, uint8) {
+	if !g.updatePieceWeight() {
+		return nil, 0
+	}
+	if g.imposed >= 0 {
+		typ := g.imposed
+		g.imposed = -1
+		if p, exits := g.createPiece(typ, x, y, z, dir, depth); p != nil {
+			return p, exits
+		}
+	}
+	for n := 0; n < 5; n++ {
+		k := g.r.NextInt(g.total)
+		for i := range g.weights {
+			w := &g.weights[i]
+			if k -= w.weight; k >= 0 {
+				continue
+			}
+			if !w.doPlace(depth) || w.typ == g.prev {
+				break
+			}
+			// 创建失败时原版会继续尝试列表中后面的部件
+			p, exits := g.createPiece(w.typ, x, y, z, dir, depth)
+			if p == nil {
+				continue
+			}
+			w.placed++
+			g.prev = w.typ
+			if !w.isValid() {
+				g.weights = append(g.weights[:i], g.weights[i+1:]...)
+			}
+			return p, exits
+		}
+	}
+	if bb, ok := g.fillerBox(x, y, z, dir); ok && bb.MinY > 1 {
+		info := strongholdPieceInfo[StrongholdFillerCorridor]
+		return &Piece{Name: info.name, Type: StrongholdFillerCorridor, Pos: Pos3{x, y, z}, BB: bb, Rotation: dir, Depth: depth}, 0
+	}
+	return nil, 0
+}
+
+// generateAndAddPiece 对应 StrongholdPieces.generateAndAddPiece。
+func (g *strongholdGen) generateAndAddPiece(x, y, z, dir, depth int) {
+	if depth > 50 || absInt(x-g.startBB.MinX) > 112 || absInt(z-g.startBB.MinZ) > 112 {
+		return
+	}
+	if p, exits := g.generatePiece(x, y, z, dir, depth+1); p != nil {
+		g.pieces = append(g.pieces, *p)
+		g.exits = append(g.exits, exits)
+		g.pending = append(g.pending, len(g.pieces)-1)
+	}
+}
+
+func (g *strongholdGen) childForward(p Piece, offX, offY int) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth:
+		g.generateAndAddPiece(bb.MinX+offX, bb.MinY+offY, bb.MinZ-1, p.Rotation, p.Depth)
+	case dirSouth:
+		g.generateAndAddPiece(bb.MinX+offX, bb.MinY+offY, bb.MaxZ+1, p.Rotation, p.Depth)
+	case dirWest:
+		g.generateAndAddPiece(bb.MinX-1, bb.MinY+offY, bb.MinZ+offX, p.Rotation, p.Depth)
+	case dirEast:
+		g.generateAndAddPiece(bb.MaxX+1, bb.MinY+offY, bb.MinZ+offX, p.Rotation, p.Depth)
+	}
+}
+
+func (g *strongholdGen) childLeft(p Piece, offY, offXZ int) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth, dirSouth:
+		g.generateAndAddPiece(bb.MinX-1, bb.MinY+offY, bb.MinZ+offXZ, dirWest, p.Depth)
+	case dirWest, dirEast:
+		g.generateAndAddPiece(bb.MinX+offXZ, bb.MinY+offY, bb.MinZ-1, dirNorth, p.Depth)
+	}
+}
+
+func (g *strongholdGen) childRight(p Piece, offY, offXZ int) {
+	bb := p.BB
+	switch p.Rotation {
+	case dirNorth, dirSouth:
+		g.generateAndAddPiece(bb.MaxX+1, bb.MinY+offY, bb.MinZ+offXZ, dirEast, p.Depth)
+	case dirWest, dirEast:
+		g.generateAndAddPiece(bb.MinX+offXZ, bb.MinY+offY, bb.MaxZ+1, dirSouth, p.Depth)
+	}
+}
+
+// addChildren 对应各部件的 addChildren。
+func (g *strongholdGen) addChildren(idx int) {
+	p, exits := g.pieces[idx], g.exits[idx]
+	switch p.Type {
+	case StrongholdStart:
+		g.imposed = StrongholdFiveCrossing
+		g.childForward(p, 1, 1)
+	case StrongholdStraight:
+		g.childForward(p, 1, 1)
+		if exits&exitLeft != 0 {
+			g.childLeft(p, 1, 2)
+		}
+		if exits&exitRight != 0 {
+			g.childRight(p, 1, 2)
+		}
+	case StrongholdPrisonHall, StrongholdStraightStairsDown, StrongholdStairsDown, StrongholdChestCorridor:
+		g.childForward(p, 1, 1)
+	case StrongholdLeftTurn:
+		if p.Rotation == dirNorth || p.Rotation == dirEast {
+			g.childLeft(p, 1, 1)
+		} else {
+			g.childRight(p, 1, 1)
+		}
+	case StrongholdRightTurn:
+		if p.Rotation == dirNorth || p.Rotation == dirEast {
+			g.childRight(p, 1, 1)
+		} else {
+			g.childLeft(p, 1, 1)
+		}
+	case StrongholdRoomCrossing:
+		g.childForward(p, 4, 1)
+		g.childLeft(p, 1, 4)
+		g.childRight(p, 1, 4)
+	case StrongholdFiveCrossing:
+		lo, hi := 3, 5
+		if p.Rotation == dirWest || p.Rotation == dirNorth {
+			lo, hi = 8-lo, 8-hi
+		}
+		g.childForward(p, 5, 1)
+		if exits&exitLeft != 0 {
+			g.childLeft(p, lo, 1)
+		}
+		if exits&exitLeftHigh != 0 {
+			g.childLeft(p, hi, 7)
+		}
+		if exits&exitRight != 0 {
+			g.childRight(p, lo, 1)
+		}
+		if exits&exitRightHigh != 0 {
+			g.childRight(p, hi, 7)
+		}
+	case StrongholdPortalRoom:
+		g.portal = idx
+	}
+}
+
+// GetStrongholdPieces 生成要塞在 chunk (chunkX, chunkZ) 处的全部部件，对应 StrongholdStructure.generatePieces，
+// 包含重试直到生成传送门房间，以及每次尝试最后的 moveBelowSeaLevel 高度调整。
+// 1.13 之前 MapGenStructure.recursiveGenerate 在构造起点前先调用一次 nextInt()，重试沿用同一个随机数，
+// 失败尝试的高度调整也会消耗随机数；之后每次重试以 seed+i 重新设置 large feature seed。
+func (f *Finder) GetStrongholdPieces(seed uint64, chunkX, chunkZ int) []Piece {
+	minY := 0
+	if f.Version >= MC_1_18 {
+		minY = -64
+	}
+	w := &WorldgenRandom{}
+	w.SetLargeFeatureSeed(seed, chunkX, chunkZ)
+	if f.Version <= MC_1_12 {
+		w.r.SkipNextN(1)
+	}
+	var g *strongholdGen
+	for i := uint64(0); ; i++ {
+		if i > 0 && f.Version >= MC_1_13 {
+			w.SetLargeFeatureSeed(seed+i, chunkX, chunkZ)
+		}
+		g = &strongholdGen{
+			r:       &w.r,
+			weights: append([]strongholdWeight(nil), strongholdWeights...),
+			prev:    -1,
+			imposed: -1,
+			portal:  -1,
+		}
+		x, z := chunkX<<4+2, chunkZ<<4+2
+		dir := g.r.NextInt(4)
+		info := strongholdPieceInfo[StrongholdStart]
+		g.startBB = makeBoundingBox(x, 64, z, dir, info.width, info.height, info.depth)
+		g.pieces = append(g.pieces, Piece{Name: info.name, Type: StrongholdStart, Pos: Pos3{x, 64, z}, BB: g.startBB, Rotation: dir})
+		g.exits = append(g.exits, 0)
+		g.addChildren(0)
+		for len(g.pending) > 0 {
+			k := g.r.NextInt(len(g.pending))
+			idx := g.pending[k]
+			g.pending = append(g.pending[:k], g.pending[k+1:]...)
+			g.addChildren(idx)
+		}
+
+		// moveBelowSeaLevel(63, minY, random, 10)，1.13 之前为 markAvailableHeight(world, random, 10)
+		bb := PiecesBoundingBox(g.pieces)
+		top := bb.MaxY - bb.MinY + 1 + minY + 1
+		if limit := 63 - 10; top < limit {
+			top += g.r.NextInt(limit - top)
+		}
+		dy := top - bb.MaxY
+		for i := range g.pieces {
+			g.pieces[i].Pos.Y += dy
+			g.pieces[i].BB.MinY += dy
+			g.pieces[i].BB.MaxY += dy
+		}
+		if g.portal >= 0 {
+			break
+		}
+	}
+	return g.pieces
+}
+
+// portalRoomSelectorCalls 是 PortalRoom.postProcess 在放置末地传送门框架之前，
+// 各个 generateBox(..., SMOOTH_STONE_SELECTOR) 的边缘方块数量之和（每个边缘方块调用一次 nextFloat）：
+// 外墙、顶部四条边、两侧平台、传送门底座，以及通往传送门的三层台阶。
+const portalRoomSelectorCalls = 652 + 14 + 14 + 14 + 7 + 8 + 8 + 25 + 9 + 6 + 3
+
+// portalFrames 是 12 个末地传送门框架在传送门房间中的局部坐标，顺序与 postProcess 中的随机数顺序一致。
+var portalFrames = [12][2]int{
+	{4, 8}, {5, 8}, {6, 8},
+	{4, 12}, {5, 12}, {6, 12},
+	{3, 9}, {3, 10}, {3, 11},
+	{7, 9}, {7, 10}, {7, 11},
+}
+
+// StrongholdPortal 描述要塞的末地传送门房间。
+type StrongholdPortal struct {
+	Room     Piece    // 传送门房间部件，Rotation 为房间朝向
+	Frames   [12]Pos3 // 传送门框架的 block 坐标
+	Eyes     [12]bool // 框架是否已放置末影之眼
+	EyeCount int
+	// Exact 为 false 表示框架所在的某个 chunk 中，有排在传送门房间之前的部件会先消耗同一个随机数，
+	// 此时 Eyes 按传送门房间最先处理计算，只是估计值。
+	Exact bool
+}
+
+// GetStrongholdPortal 返回 chunk (chunkX, chunkZ) 处要塞的传送门房间位置、朝向以及预先放置的末影之眼（1.16+）。
+// 末影之眼由框架所在 chunk 的要塞特征随机数（STRONGHOLDS 步骤，序号 0）决定：每个 chunk 中传送门房间
+// 先为石砖选择器消耗固定数量的随机数，再为 12 个框架各调用一次 nextFloat() > 0.9，只有落在该 chunk 的框架生效。
+func (f *Finder) GetStrongholdPortal(seed uint64, chunkX, chunkZ int) (*StrongholdPortal, error) {
+	if f.Version < MC_1_16_1 {
+		return nil, fmt.Errorf("portal eyes not supported in version %v", f.Version)
+	}
+	pieces := f.GetStrongholdPieces(seed, chunkX, chunkZ)
+	room := -1
+	for i := range pieces {
+		if pieces[i].Type == StrongholdPortalRoom {
+			room = i
+			break
+		}
+	}
+	if room < 0 {
+		return nil, fmt.Errorf("no portal room in stronghold at chunk (%d, %d)", chunkX, chunkZ)
+	}
+
+	sp := &StrongholdPortal{Room: pieces[room], Exact: true}
+	done := make(map[Pos]bool)
+	for i, fr := range portalFrames {
+		sp.Frames[i] = pieceWorldPos(&sp.Room, fr[0], 3, fr[1])
+	}
+	for _, fp := range sp.Frames {
+		c := Pos{fp.X >> 4, fp.Z >> 4}
+		if done[c] {
+			continue
+		}
+		done[c] = true
+		cb := BoundingBox{c.X << 4, -1 << 20, c.Z << 4, c.X<<4 + 15, 1 << 20, c.Z<<4 + 15}
+		for _, p := range pieces[:room] {
+			if p.BB.Intersects(cb) {
+				sp.Exact = false
+				break
+			}
+		}
+		r := f.FeatureRandom(seed, c.X, c.Z, 0, StepStrongholds)
+		for i := 0; i < portalRoomSelectorCalls; i++ {
+			r.NextFloat()
+		}
+		for i := range sp.Frames {
+			eye := r.NextFloat() > 0.9
+			if sp.Frames[i].X>>4 == c.X && sp.Frames[i].Z>>4 == c.Z {
+				sp.Eyes[i] = eye
+			}
+		}
+	}
+	for _, e := range sp.Eyes {
+		if e {
+			sp.EyeCount++
+		}
+	}
+	return sp, nil
+}
@@ -0,0 +1,78 @@
+package gobiomes
+
+import "testing"
+
+func TestGetStrongholdPortal(t *testing.T) {
+	tests := []struct {
+		mc             int
+		seed           uint64
+		chunkX, chunkZ int
+		room           BoundingBox
+		rotation       int
+		eyes           [12]bool
+	}{
+		{MC_1_16_5, 2483313382402348964, 57, 66, BoundingBox{942, 34, 1054, 957, 41, 1064}, 1, [12]bool{}},
+		{MC_1_16_5, 2483313382402348964, 31, -84, BoundingBox{496, 18, -1287, 506, 25, -1272}, 2, [12]bool{5: true}},
+		{MC_1_17_1, 268143611308591, 58, -78, BoundingBox{934, 28, -1216, 944, 35, -1201}, 2, [12]bool{2: true}},
+		{MC_1_17_1, 268143611308591, -93, -13, BoundingBox{-1523, 36, -181, -1513, 43, -166}, 2, [12]bool{9: true}},
+		{MC_1_20_6, 42, -20, -141, BoundingBox{-361, -26, -2224, -346, -19, -2214}, 3, [12]bool{11: true}},
+		{MC_1_21_1, 1, -5, 60, BoundingBox{-140, -14, 954, -125, -7, 964}, 3, [12]bool{1: true, 4: true, 11: true}},
+	}
+	for _, tt := range tests {
+		sp, err := NewFinder(tt.mc).GetStrongholdPortal(tt.seed, tt.chunkX, tt.chunkZ)
+		if err != nil {
+			t.Fatalf("mc %d seed %d: %v", tt.mc, tt.seed, err)
+		}
+		if sp.Room.BB != tt.room || sp.Room.Rotation != tt.rotation {
+			t.Errorf("mc %d seed %d: room %v rotation %d, want %v rotation %d", tt.mc, tt.seed, sp.Room.BB, sp.Room.Rotation, tt.room, tt.rotation)
+		}
+		if !sp.Exact {
+			t.Errorf("mc %d seed %d: eyes not exact", tt.mc, tt.seed)
+		}
+		n := 0
+		for _, e := range tt.eyes {
+			if e {
+				n++
+			}
+		}
+		if sp.Eyes != tt.eyes || sp.EyeCount != n {
+			t.Errorf("mc %d seed %d: eyes %v (%d), want %v (%d)", tt.mc, tt.seed, sp.Eyes, sp.EyeCount, tt.eyes, n)
+		}
+		for i, fp := range sp.Frames {
+			if !sp.Room.BB.Contains(fp.X, fp.Y, fp.Z) {
+				t.Errorf("mc %d seed %d: frame %d at %v outside room", tt.mc, tt.seed, i, fp)
+			}
+		}
+	}
+}
+
+func TestGetStrongholdPiecesLegacy(t *testing.T) {
+	tests := []struct {
+		mc             int
+		seed           uint64
+		chunkX, chunkZ int
+		pieces         int
+		start, portal  BoundingBox
+	}{
+		{MC_1_12_2, 1, -8, -113, 7, BoundingBox{-126, 24, -1806, -122, 34, -1802}, BoundingBox{-98, 16, -1795, -83, 23, -1785}},
+		{MC_1_12_2, 14274599075807262, -129, 56, 135, BoundingBox{-2062, 28, 898, -2058, 38, 902}, BoundingBox{-2065, 22, 931, -2050, 29, 941}},
+		{MC_1_7_10, 2483313382402348964, 28, 38, 121, BoundingBox{450, 32, 610, 454, 42, 614}, BoundingBox{432, 24, 562, 447, 31, 572}},
+	}
+	for _, tt := range tests {
+		pieces := NewFinder(tt.mc).GetStrongholdPieces(tt.seed, tt.chunkX, tt.chunkZ)
+		portal := -1
+		for i := range pieces {
+			if pieces[i].Type == StrongholdPortalRoom {
+				portal = i
+			}
+		}
+		if len(pieces) != tt.pieces || pieces[0].BB != tt.start || portal < 0 || pieces[portal].BB != tt.portal {
+			got := BoundingBox{}
+			if portal >= 0 {
+				got = pieces[portal].BB
+			}
+			t.Errorf("mc %d seed %d: %d pieces, start %v, portal %v; want %d, %v, %v",
+				tt.mc, tt.seed, len(pieces), pieces[0].BB, got, tt.pieces, tt.start, tt.portal)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+package gobiomes
+
+import (
+	"fmt"
+)
+
+// StructurePos3 是带起点高度的结构位置。
+type StructurePos3 struct {
+	Pos3
+	Terrain bool // Y 依赖地形：此时 Y 为原版的初始高度、上界或估计值
+}
+
+// GetStructureY 返回结构在 pos（GetStructurePos 返回的生成尝试位置）处的起点高度。
+// 第二个返回值为 true 表示高度依赖地形，返回值仅为估计。biome 为 pos 处的生物群系，用于确定变种。
+//
+//   - 远古城市：起点固定在 y=-27；堡垒遗迹：起点固定在 y=33
+//   - 试炼密室：起点在 [-40, -20] 内由结构 RNG 均匀选取
+//   - 紫水晶晶洞：装饰 RNG 选取的高度
+//   - 废弃矿井、下界要塞、要塞：由部件生成后的高度调整决定（恶地矿井按海平面抬升，1.18+ 依赖地形）
+//   - 下界废弃传送门：RNG 选取的初始高度，生成时会向下移动到地面，因此为上界
+//   - 其他地表结构：依赖高度图，返回海平面附近的估计值
+func (f *Finder) GetStructureY(st StructureType, seed uint64, pos Pos, biome Biome) (int, bool, error) {
+	mc := f.Version
+	if st == Stronghold {
+		// 要塞不是基于 region 的结构，没有 StructureConfig
+		return f.GetStrongholdPieces(seed, pos.X>>4, pos.Z>>4)[0].BB.MinY, false, nil
+	}
+	config, err := f.GetStructureConfig(st)
+	if err != nil {
+		return 0, false, err
+	}
+	cx, cz := pos.X>>4, pos.Z>>4
+
+	switch st {
+	case AncientCity:
+		return -27, false, nil
+
+	case Bastion:
+		return 33, false, nil
+
+	case TrialChambers:
+		r := &Rng{seed: f.ChunkGenerateRnd(seed, cx, cz)}
+		return r.NextInt(21) - 40, false, nil
+
+	case Geode:
+		p, ok := getDecorationPos(mc, config, seed, cx, cz)
+		if !ok {
+			return 0, false, fmt.Errorf("no geode in chunk (%d, %d)", cx, cz)
+		}
+		return p.Y, false, nil
+
+	case Mineshaft:
+		mesa := IsMineshaftMesa(mc, biome)
+		pieces := f.GetMineshaftPieces(seed, cx, cz, mesa)
+		if len(pieces) == 0 {
+			return 0, false, fmt.Errorf("no mineshaft pieces at chunk (%d, %d)", cx, cz)
+		}
+		// 1.18+ 地表高于海平面时，恶地矿井在海平面与地表之间随机选取高度
+		return pieces[0].BB.MinY, mesa && mc >= MC_1_18, nil
+
+	case Fortress:
+		pieces := f.GetFortressPieces(seed, cx, cz)
+		if len(pieces) == 0 {
+			return 0, false, fmt.Errorf("no fortress pieces at chunk (%d, %d)", cx, cz)
+		}
+		return pieces[0].BB.MinY, false, nil
+
+	case Monument:
+		return 39, false, nil
+
+	case RuinedPortalN:
+		v, err := f.GetVariant(st, seed, pos.X, pos.Z, biome)
+		if err != nil {
+			return 0, false, err
+		}
+		// 重放 GetVariant 中的 RNG 调用，再按 findSuitableY 的 IN_NETHER 分支选取高度
+		r := &Rng{seed: f.ChunkGenerateRnd(seed, cx, cz)}
+		r.NextFloat()
+		if r.NextFloat() < 0.05 {
+			r.NextInt(3)
+		} else {
+			r.NextInt(10)
+		}
+		r.NextInt(4)
+		r.NextFloat()
+		switch {
+		case v.Airpocket:
+			return 32 + r.NextInt(69), true, nil
+		case r.NextFloat() < 0.5:
+			return 27 + r.NextInt(3), true, nil
+		}
+		return 29 + r.NextInt(72), true, nil
+
+	}
+
+	switch config.Dim {
+	case DimNether:
+		return 64, true, nil
+	case DimEnd:
+		return 64, true, nil
+	}
+	return 63, true, nil
+}
+
+// GetStructurePos3 返回结构在指定 region 内生成尝试的 3D 位置。没有生成尝试时返回 nil, nil。
+func (f *Finder) GetStructurePos3(st StructureType, seed uint64, regX, regZ int, biome Biome) (*StructurePos3, error) {
+	pos, err := f.GetStructurePos(st, seed, regX, regZ)
+	if err != nil || pos == nil {
+		return nil, err
+	}
+	y, terrain, err := f.GetStructureY(st, seed, *pos, biome)
+	if err != nil {
+		return nil, err
+	}
+	return &StructurePos3{Pos3{pos.X, y, pos.Z}, terrain}, nil
+}
+
+// GetStructurePos3 返回结构在指定 region 内生成尝试的 3D 位置，使用生成器的种子和该位置的生物群系。
+func (gen *Generator) GetStructurePos3(st StructureType, regX, regZ int) (*StructurePos3, error) {
+	f := NewFinder(gen.Version)
+	pos, err := f.GetStructurePos(st, gen.Seed, regX, regZ)
+	if err != nil || pos == nil {
+		return nil, err
+	}
+	biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
+	y, terrain, err := f.GetStructureY(st, gen.Seed, *pos, biome)
+	if err != nil {
+		return nil, err
+	}
+	return &StructurePos3{Pos3{pos.X, y, pos.Z}, terrain}, nil
+}
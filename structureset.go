@@ -0,0 +1,131 @@
+package gobiomes
+
+// 结构集（structure set）语义：同一结构集中的结构共享一次生成尝试，由一次加权随机选出实际生成的结构；
+// 排除区（exclusion zone）使结构不能在另一个结构集的生成尝试附近生成。
+
+// netherComplexes 是 1.18+ nether_complexes 结构集中的结构及其权重。
+var netherComplexes = []struct {
+	st     StructureType
+	weight int
+}{
+	{Fortress, 2},
+	{Bastion, 3},
+}
+
+// selectNetherComplex 返回 1.18+ 下界结构集在一次生成尝试中实际生成的结构，没有结构能生成时返回 false。
+// r 为尝试所在 chunk 的 ChunkGenerateRnd；viable 判断结构能否在该处生成。
+// 对应 ChunkGenerator.createStructures：按权重选出一个结构，无法生成时将其移除后在剩余结构中重新选择。
+func selectNetherComplex(r *Rng, viable func(StructureType) bool) (StructureType, bool) {
+	var removed [2]bool
+	total := 0
+	for _, e := range netherComplexes {
+		total += e.weight
+	}
+	for total > 0 {
+		k := r.NextInt(total)
+		for i, e := range netherComplexes {
+			if removed[i] {
+				continue
+			}
+			if k -= e.weight; k >= 0 {
+				continue
+			}
+			if viable(e.st) {
+				return e.st, true
+			}
+			removed[i] = true
+			total -= e.weight
+			break
+		}
+	}
+	return 0, false
+}
+
+// netherComplexAt 返回 1.18+ 在 block 坐标 (x, z) 处的下界结构集生成尝试中实际生成的结构，
+// biome 为玄武岩三角洲时选中的堡垒遗迹无法生成。本包不采样下界生物群系（GetBiomeAt 在下界返回 None），
+// 因此 Generator 传入的生物群系不会是玄武岩三角洲，结果总是加权选择直接选中的结构。
+func (gen *Generator) netherComplexAt(x, z int, biome Biome) (StructureType, bool) {
+	r := &Rng{seed: NewFinder(gen.Version).ChunkGenerateRnd(gen.Seed, x>>4, z>>4)}
+	return selectNetherComplex(r, func(st StructureType) bool {
+		return st != Bastion || biome != BasaltDeltas
+	})
+}
+
+// outpostVillageExclusion 是前哨站与村庄生成尝试之间的最小距离（chunk）。
+const outpostVillageExclusion = 10
+
+// villageAttemptsNear 返回与 chunk (cx, cz) 的切比雪夫距离不超过 radius 的村庄生成尝试位置。
+func (f *Finder) villageAttemptsNear(seed uint64, cx, cz, radius int) ([]Pos, error) {
+	config, err := f.GetStructureConfig(Village)
+	if err != nil {
+		return nil, err
+	}
+	var out []Pos
+	rx0, rx1 := floorDiv(cx-radius, config.RegionSize), floorDiv(cx+radius, config.RegionSize)
+	rz0, rz1 := floorDiv(cz-radius, config.RegionSize), floorDiv(cz+radius, config.RegionSize)
+	for rz := rz0; rz <= rz1; rz++ {
+		for rx := rx0; rx <= rx1; rx++ {
+			p, err := f.GetStructurePos(Village, seed, rx, rz)
+			if err != nil {
+				return nil, err
+			}
+			if p != nil && absInt(p.X>>4-cx) <= radius && absInt(p.Z>>4-cz) <= radius {
+				out = append(out, *p)
+			}
+		}
+	}
+	return out, nil
+}
+
+// GetStructurePos 返回结构在指定 region 内实际会生成的位置，没有时返回 nil, nil。
+// 在 Finder.GetStructurePos 的基础上检查生物群系以及依赖生物群系的结构集规则：
+// 1.16 以前前哨站附近的村庄需要能够生成才会排除前哨站。下界生物群系不被采样，因此不检查堡垒遗迹
+// 是否位于玄武岩三角洲，1.18+ 原版在这种情况下改为生成下界要塞的回退也不会发生。
+func (gen *Generator) GetStructurePos(st StructureType, regX, regZ int) (*Pos, error) {
+	f := NewFinder(gen.Version)
+	var pos *Pos
+	if st == Fortress && gen.Version >= MC_1_18 {
+		// Finder 只报告加权选择直接选中的下界要塞
+		config, err := f.GetStructureConfig(st)
+		if err != nil {
+			return nil, err
+		}
+		p := getFeaturePos(config, gen.Seed&mask48, regX, regZ)
+		pos = &p
+	} else {
+		var err error
+		if pos, err = f.GetStructurePos(st, gen.Seed, regX, regZ); err != nil || pos == nil {
+			return nil, err
+		}
+	}
+	if !gen.IsViableStructurePos(st, pos.X, pos.Z, 0) {
+		return nil, nil
+	}
+	return pos, nil
+}
+
+// ResolveFeatureType 返回 1.12 及以前 Feature 生成尝试在生物群系 biome 中实际生成的神庙类型，不生成时返回 false。
+// 对应 ScatteredFeatureStart 的判断顺序和 MapGenScatteredFeature 的生物群系列表。
+func ResolveFeatureType(mc int, biome Biome) (StructureType, bool) {
+	if mc > MC_1_12 {
+		return 0, false
+	}
+	switch biome {
+	case Jungle, JungleHills:
+		return JunglePyramid, mc >= MC_1_3
+	case Swamp:
+		return SwampHut, mc >= MC_1_4
+	case Desert, DesertHills:
+		return DesertPyramid, mc >= MC_1_3
+	case SnowyTundra, SnowyTaiga:
+		return Igloo, mc >= MC_1_9
+	}
+	return 0, false
+}
+
+// ResolveFeature 返回 1.12 及以前在 block 坐标 (x, z) 处的 Feature 生成尝试实际生成的神庙类型，
+// 原版在尝试所在 chunk 的 (8, 8) 处采样生物群系。
+func (gen *Generator) ResolveFeature(x, z int) (StructureType, bool) {
+	biome := gen.GetBiomeAt(1, x&^15+8, 64, z&^15+8)
+	return ResolveFeatureType(gen.Version, biome)
+}
@@ -31,3 +31,36 @@ func NewRange2D(scale, x, z, sx, sz int) Range {
 func NewRange3D(scale, x, z, sx, sz, y, sy int) Range {
 	return Range{Scale: scale, X: x, Z: z, SX: sx, SZ: sz, Y: y, SY: sy}
 }
+
+// BoundingBox 表示 block 坐标下的轴对齐包围盒，两端均包含在内。
+type BoundingBox struct {
+	MinX, MinY, MinZ int
+	MaxX, MaxY, MaxZ int
+}
+
+// Contains 判断点是否位于包围盒内。
+func (b BoundingBox) Contains(x, y, z int) bool {
+	return x >= b.MinX && x <= b.MaxX && y >= b.MinY && y <= b.MaxY && z >= b.MinZ && z <= b.MaxZ
+}
+
+// Center 返回包围盒的中心点。
+func (b BoundingBox) Center() Pos3 {
+	return Pos3{X: (b.MinX + b.MaxX) / 2, Y: (b.MinY + b.MaxY) / 2, Z: (b.MinZ + b.MaxZ) / 2}
+}
+
+// Intersects 判断两个包围盒是否相交。
+func (b BoundingBox) Intersects(o BoundingBox) bool {
+	return b.MaxX >= o.MinX && b.MinX <= o.MaxX &&
+		b.MaxZ >= o.MinZ && b.MinZ <= o.MaxZ &&
+		b.MaxY >= o.MinY && b.MinY <= o.MaxY
+}
+
+// Piece 对应 cubiomes 的 Piece，表示结构中的一个部件。
+type Piece struct {
+	Name     string      // 部件名（模板名或部件类型名）
+	Type     int         // 部件类型，取值由具体结构定义
+	Pos      Pos3        // 部件原点
+	BB       BoundingBox // 部件包围盒
+	Rotation int         // 0: 不旋转, 1: 顺时针 90, 2: 180, 3: 逆时针 90
+	Depth    int         // 生成深度
+}
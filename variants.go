@@ -0,0 +1,420 @@
+package gobiomes
+
+import (
+	"fmt"
+	"strings"
+)
+
+// StructureVariant 对应 cubiomes 的 StructureVariant，描述结构起始部件的变种信息。
+type StructureVariant struct {
+	Abandoned bool   // 僵尸村庄
+	Start     int    // 起始部件在模板池中的序号，-1 表示未知
+	Piece     string // 起始部件的模板名
+	Biome     Biome  // 决定变种的生物群系（村庄风格）
+	Rotation  int    // 0: 不旋转, 1: 顺时针 90, 2: 180, 3: 逆时针 90
+	Mirror    bool   // 前后镜像（废弃传送门）
+
+	PortalType  int  // 废弃传送门的放置类型，见 PortalStandard 等常量
+	Giant       bool // 巨型废弃传送门
+	Underground bool // 废弃传送门埋于地下或山体中
+	Airpocket   bool // 废弃传送门周围保留空气
+	Basement    bool // 雪屋带有地下室
+	Size        int  // 雪屋地下室梯子段数
+	Beached     bool // 沉船搁浅于沙滩
+	Warm        bool // 暖水海底废墟
+	Large       bool // 大型海底废墟
+	Cluster     bool // 大型海底废墟周围生成废墟群
+
+	// 起始部件相对结构位置的 block 偏移与尺寸（已考虑旋转）
+	X, Y, Z    int
+	SX, SY, SZ int
+}
+
+// 废弃传送门的放置类型，对应 RuinedPortalFeature.Type。
+const (
+	PortalStandard = iota
+	PortalDesert
+	PortalJungle
+	PortalSwamp
+	PortalMountain
+	PortalOcean
+	PortalNether
+)
+
+// 堡垒遗迹起始部件，Start 字段取值。
+const (
+	BastionHousing      = 0 // units/air_base
+	BastionHoglinStable = 1 // hoglin_stable/air_base
+	BastionTreasure     = 2 // treasure/big_air_full
+	BastionBridge       = 3 // bridge/starting_pieces/entrance_base
+)
+
+// poolElement 是拼图结构起始模板池中的一项。
+type poolElement struct {
+	weight     int
+	start      int
+	piece      string
+	sx, sy, sz int
+	abandoned  bool
+}
+
+// 村庄起始模板池（1.14+），权重与 data/minecraft/worldgen/template_pool/village/*/town_centers 一致。
+var (
+	villageStartsPlains = []poolElement{
+		{50, 0, "plains_fountain_01", 9, 4, 9, false},
+		{50, 1, "plains_meeting_point_1", 10, 7, 10, false},
+		{50, 2, "plains_meeting_point_2", 8, 5, 15, false},
+		{50, 3, "plains_meeting_point_3", 11, 9, 11, false},
+		{1, 0, "plains_fountain_01", 9, 4, 9, true},
+		{1, 1, "plains_meeting_point_1", 10, 7, 10, true},
+		{1, 2, "plains_meeting_point_2", 8, 5, 15, true},
+		{1, 3, "plains_meeting_point_3", 11, 9, 11, true},
+	}
+	villageStartsDesert = []poolElement{
+		{98, 1, "desert_meeting_point_1", 17, 6, 9, false},
+		{98, 2, "desert_meeting_point_2", 12, 6, 12, false},
+		{49, 3, "desert_meeting_point_3", 15, 6, 15, false},
+		{2, 1, "desert_meeting_point_1", 17, 6, 9, true},
+		{2, 2, "desert_meeting_point_2", 12, 6, 12, true},
+		{1, 3, "desert_meeting_point_3", 15, 6, 15, true},
+	}
+	villageStartsSavanna = []poolElement{
+		{100, 1, "savanna_meeting_point_1", 14, 5, 12, false},
+		{50, 2, "savanna_meeting_point_2", 11, 6, 11, false},
+		{150, 3, "savanna_meeting_point_3", 9, 6, 11, false},
+		{150, 4, "savanna_meeting_point_4", 9, 6, 9, false},
+		{2, 1, "savanna_meeting_point_1", 14, 5, 12, true},
+		{1, 2, "savanna_meeting_point_2", 11, 6, 11, true},
+		{3, 3, "savanna_meeting_point_3", 9, 6, 11, true},
+		{3, 4, "savanna_meeting_point_4", 9, 6, 9, true},
+	}
+	villageStartsTaiga = []poolElement{
+		{49, 1, "taiga_meeting_point_1", 22, 3, 18, false},
+		{49, 2, "taiga_meeting_point_2", 9, 7, 9, false},
+		{1, 1, "taiga_meeting_point_1", 22, 3, 18, true},
+		{1, 2, "taiga_meeting_point_2", 9, 7, 9, true},
+	}
+	villageStartsSnowy = []poolElement{
+		{100, 1, "snowy_meeting_point_1", 12, 8, 8, false},
+		{50, 2, "snowy_meeting_point_2", 11, 5, 9, false},
+		{150, 3, "snowy_meeting_point_3", 7, 7, 7, false},
+		{2, 1, "snowy_meeting_point_1", 12, 8, 8, true},
+		{1, 2, "snowy_meeting_point_2", 11, 5, 9, true},
+		{3, 3, "snowy_meeting_point_3", 7, 7, 7, true},
+	}
+)
+
+// bastionStarts 是 bastion/starts 模板池，四项权重相同。
+var bastionStarts = []poolElement{
+	{1, BastionHousing, "bastion/units/air_base", 46, 24, 46, false},
+	{1, BastionHoglinStable, "bastion/hoglin_stable/air_base", 30, 24, 48, false},
+	{1, BastionTreasure, "bastion/treasure/big_air_full", 38, 48, 38, false},
+	{1, BastionBridge, "bastion/bridge/starting_pieces/entrance_base", 16, 32, 32, false},
+}
+
+// 沉船模板，顺序与 ShipwreckPieces 中的 STRUCTURE_LOCATION_BEACHED / STRUCTURE_LOCATION_OCEAN 一致。
+var (
+	shipwrecksBeached = []string{
+		"with_mast", "sideways_full", "sideways_fronthalf", "sideways_backhalf",
+		"rightsideup_full", "rightsideup_fronthalf", "rightsideup_backhalf",
+		"with_mast_degraded", "rightsideup_full_degraded",
+		"rightsideup_fronthalf_degraded", "rightsideup_backhalf_degraded",
+	}
+	shipwrecksOcean = []string{
+		"with_mast", "upsidedown_full", "upsidedown_fronthalf", "upsidedown_backhalf",
+		"sideways_full", "sideways_fronthalf", "sideways_backhalf",
+		"rightsideup_full", "rightsideup_fronthalf", "rightsideup_backhalf",
+		"with_mast_degraded", "upsidedown_full_degraded", "upsidedown_fronthalf_degraded",
+		"upsidedown_backhalf_degraded", "sideways_full_degraded", "sideways_fronthalf_degraded",
+		"sideways_backhalf_degraded", "rightsideup_full_degraded",
+		"rightsideup_fronthalf_degraded", "rightsideup_backhalf_degraded",
+	}
+)
+
+// 雪屋的顶部、梯子和地下室模板，对应 IglooPieces 中的 PIVOTS 和 OFFSETS：
+// 模板放在起点加偏移处，绕模板局部坐标中的旋转中心旋转。
+var (
+	iglooNames   = [3]string{"igloo/top", "igloo/middle", "igloo/bottom"}
+	iglooSizes   = [3]Pos3{{7, 5, 8}, {3, 3, 3}, {7, 6, 9}}
+	iglooPivots  = [3]Pos3{{3, 5, 5}, {1, 3, 1}, {3, 6, 7}}
+	iglooOffsets = [3]Pos3{{0, 0, 0}, {2, -3, 4}, {0, -3, -2}}
+)
+
+// iglooPieces 返回 1.13+ 雪屋在 block 坐标 (x, z) 处的部件，v 为 GetVariant 返回的变种。
+// 对应 IglooPieces.addPieces：顶部放在 y=90，带地下室时底部房间和 Size-1 段梯子依次向下错开 3 格。
+func iglooPieces(x, z int, v *StructureVariant) []Piece {
+	var out []Piece
+	add := func(i, down int) {
+		o, s := iglooOffsets[i], iglooSizes[i]
+		p := Pos3{x + o.X, 90 + o.Y - down, z + o.Z}
+		out = append(out, Piece{Name: iglooNames[i], Pos: p, BB: pivotBoundingBox(p, v.Rotation, s.X, s.Y, s.Z, iglooPivots[i]), Rotation: v.Rotation})
+	}
+	if v.Basement {
+		add(2, 3*v.Size)
+		for j := 0; j < v.Size-1; j++ {
+			add(1, 3*j)
+		}
+	}
+	add(0, 0)
+	return out
+}
+
+// shipwreckPivot 对应 ShipwreckPieces.PIVOT，沉船模板绕该点旋转。
+var shipwreckPivot = Pos3{4, 0, 15}
+
+// shipwreckSize 返回沉船模板的尺寸，破损版本与完整版本相同。
+func shipwreckSize(piece string) (sx, sy, sz int) {
+	switch strings.TrimSuffix(piece, "_degraded") {
+	case "with_mast":
+		return 9, 21, 28
+	case "rightsideup_backhalf", "upsidedown_backhalf":
+		return 9, 9, 16
+	case "sideways_backhalf":
+		return 9, 9, 17
+	case "upsidedown_fronthalf":
+		return 9, 9, 22
+	case "rightsideup_fronthalf", "sideways_fronthalf":
+		return 9, 9, 24
+	}
+	return 9, 9, 28
+}
+
+// 海底废墟模板数量，对应 OceanRuinPieces 中的各模板数组。
+var (
+	oceanRuinsWarm     = []string{"warm_1", "warm_2", "warm_3", "warm_4", "warm_5", "warm_6", "warm_7", "warm_8"}
+	oceanRuinsWarmBig  = []string{"big_warm_4", "big_warm_5", "big_warm_6", "big_warm_7"}
+	oceanRuinsBrick    = []string{"brick_1", "brick_2", "brick_3", "brick_4", "brick_5", "brick_6", "brick_7", "brick_8"}
+	oceanRuinsBrickBig = []string{"big_brick_1", "big_brick_2", "big_brick_3", "big_brick_8"}
+)
+
+// ruinedPortalType 返回生物群系对应的废弃传送门放置类型。
+func ruinedPortalType(mc int, st StructureType, biome Biome) int {
+	if st == RuinedPortalN || GetCategory(mc, biome) == NetherWastes {
+		return PortalNether
+	}
+	switch biome {
+	case Desert, DesertHills, DesertLakes:
+		return PortalDesert
+	case Swamp, SwampHills, MangroveSwamp:
+		return PortalSwamp
+	case SparseJungle:
+		return PortalJungle
+	case SavannaPlateau, ShatteredSavanna, ShatteredSavannaPlateau, StoneShore,
+		WindsweptSavanna, WindsweptHills, WindsweptForest, WindsweptGravellyHills,
+		WoodedBadlands, Meadow, Grove, SnowySlopes, JaggedPeaks, FrozenPeaks, StonyPeaks,
+		CherryGrove:
+		return PortalMountain
+	}
+	switch GetCategory(mc, biome) {
+	case Jungle:
+		return PortalJungle
+	case Mountains, Mesa, BadlandsPlateau:
+		return PortalMountain
+	case Ocean:
+		return PortalOcean
+	}
+	return PortalStandard
+}
+
+// villageStyle 返回村庄风格对应的生物群系及其起始模板池，不支持村庄的生物群系返回 None。
+func villageStyle(mc int, biome Biome) (Biome, []poolElement) {
+	switch biome {
+	case Plains:
+		return Plains, villageStartsPlains
+	case Meadow:
+		if mc >= MC_1_18 {
+			return Plains, villageStartsPlains
+		}
+	case Desert:
+		return Desert, villageStartsDesert
+	case Savanna:
+		return Savanna, villageStartsSavanna
+	case Taiga:
+		return Taiga, villageStartsTaiga
+	case SnowyTundra, SnowyPlains:
+		return SnowyTundra, villageStartsSnowy
+	}
+	return None, nil
+}
+
+// pickWeighted 模拟 StructureTemplatePool.getRandomTemplate 的加权抽取。
+func pickWeighted(r *Rng, starts []poolElement) poolElement {
+	total := 0
+	for _, s := range starts {
+		total += s.weight
+	}
+	t := r.NextInt(total)
+	for _, s := range starts {
+		if t < s.weight {
+			return s
+		}
+		t -= s.weight
+	}
+	return starts[len(starts)-1]
+}
+
+// pivotBoundingBox 与 templateBoundingBox 相同，但模板绕局部坐标 pivot 而不是原点旋转，
+// 对应设置了 rotationPivot 的 StructureTemplate.getBoundingBox。
+func pivotBoundingBox(p Pos3, rot, sx, sy, sz int, pivot Pos3) BoundingBox {
+	r := rotatePos(pivot.X, pivot.Y, pivot.Z, rot)
+	return templateBoundingBox(Pos3{p.X + pivot.X - r.X, p.Y, p.Z + pivot.Z - r.Z}, rot, sx, sy, sz)
+}
+
+// rotateVariant 按旋转方向设置起始部件的偏移和尺寸，起始部件绕模板局部坐标 pivot 旋转。
+// 拼图结构的起始部件绕模板原点旋转。
+func (v *StructureVariant) rotateVariant(sx, sy, sz int, pivot Pos3) {
+	bb := pivotBoundingBox(Pos3{}, v.Rotation, sx, sy, sz, pivot)
+	v.X, v.Z = bb.MinX, bb.MinZ
+	v.SX, v.SY, v.SZ = bb.MaxX-bb.MinX+1, sy, bb.MaxZ-bb.MinZ+1
+}
+
+// GetVariant 返回结构在 block 坐标 (blockX, blockZ) 处的变种信息。
+// biome 为该位置的生物群系；若结构在该生物群系下不会生成则返回 nil。
+// 对应 finders.c 中的 getVariant。
+func (f *Finder) GetVariant(st StructureType, seed uint64, blockX, blockZ int, biome Biome) (*StructureVariant, error) {
+	mc := f.Version
+	v := &StructureVariant{Start: -1, Biome: None}
+	r := &Rng{seed: f.ChunkGenerateRnd(seed, blockX>>4, blockZ>>4)}
+
+	switch st {
+	case Village:
+		if mc <= MC_1_9 {
+			return nil, fmt.Errorf("village variants not supported in version %v", mc)
+		}
+		style, starts := villageStyle(mc, biome)
+		if starts == nil {
+			return nil, nil
+		}
+		v.Biome = style
+		if mc <= MC_1_13 {
+			// 旧版村庄在 StructureStart 构造时先消耗若干次随机数，再判定僵尸村庄
+			if mc == MC_1_13 {
+				r.SkipNextN(10)
+			} else {
+				r.SkipNextN(11)
+			}
+			v.Abandoned = r.NextInt(50) == 0
+			return v, nil
+		}
+		v.Rotation = r.NextInt(4)
+		s := pickWeighted(r, starts)
+		v.Start = s.start
+		v.Piece = s.piece
+		v.Abandoned = s.abandoned
+		v.rotateVariant(s.sx, s.sy, s.sz, Pos3{})
+		return v, nil
+
+	case Bastion:
+		if mc < MC_1_16_1 {
+			return nil, fmt.Errorf("bastion variants not supported in version %v", mc)
+		}
+		v.Rotation = r.NextInt(4)
+		s := pickWeighted(r, bastionStarts)
+		v.Start = s.start
+		v.Piece = s.piece
+		v.rotateVariant(s.sx, s.sy, s.sz, Pos3{})
+		return v, nil
+
+	case RuinedPortal, RuinedPortalN:
+		if mc < MC_1_16_1 {
+			return nil, fmt.Errorf("ruined portal variants not supported in version %v", mc)
+		}
+		v.Biome = biome
+		v.PortalType = ruinedPortalType(mc, st, biome)
+		switch v.PortalType {
+		case PortalJungle, PortalNether:
+			v.Airpocket = r.NextFloat() < 0.5
+		case PortalMountain, PortalStandard:
+			v.Underground = r.NextFloat() < 0.5
+			v.Airpocket = v.Underground || r.NextFloat() < 0.5
+		}
+		if r.NextFloat() < 0.05 {
+			v.Giant = true
+			v.Start = r.NextInt(3)
+			v.Piece = fmt.Sprintf("ruined_portal/giant_portal_%d", v.Start+1)
+		} else {
+			v.Start = r.NextInt(10)
+			v.Piece = fmt.Sprintf("ruined_portal/portal_%d", v.Start+1)
+		}
+		v.Rotation = r.NextInt(4)
+		v.Mirror = r.NextFloat() >= 0.5
+		return v, nil
+
+	case Igloo:
+		if mc < MC_1_13 {
+			return nil, fmt.Errorf("igloo variants not supported in version %v", mc)
+		}
+		v.Rotation = r.NextInt(4)
+		if r.NextDouble() < 0.5 {
+			v.Basement = true
+			v.Size = r.NextInt(8) + 4
+		}
+		v.Start = 0
+		v.Piece = iglooNames[0]
+		v.rotateVariant(iglooSizes[0].X, iglooSizes[0].Y, iglooSizes[0].Z, iglooPivots[0])
+		return v, nil
+
+	case Shipwreck:
+		if mc < MC_1_13 {
+			return nil, fmt.Errorf("shipwreck variants not supported in version %v", mc)
+		}
+		v.Biome = biome
+		v.Beached = biome == Beach || biome == SnowyBeach
+		v.Rotation = r.NextInt(4)
+		pieces := shipwrecksOcean
+		if v.Beached {
+			pieces = shipwrecksBeached
+		}
+		v.Start = r.NextInt(len(pieces))
+		v.Piece = "shipwreck/" + pieces[v.Start]
+		sx, sy, sz := shipwreckSize(pieces[v.Start])
+		v.rotateVariant(sx, sy, sz, shipwreckPivot)
+		return v, nil
+
+	case OceanRuin:
+		if mc < MC_1_13 {
+			return nil, fmt.Errorf("ocean ruin variants not supported in version %v", mc)
+		}
+		v.Biome = biome
+		switch biome {
+		case WarmOcean, LukewarmOcean, DeepWarmOcean, DeepLukewarmOcean:
+			v.Warm = true
+		}
+		v.Rotation = r.NextInt(4)
+		v.Large = r.NextFloat() <= 0.3
+		var pieces []string
+		switch {
+		case v.Warm && v.Large:
+			pieces = oceanRuinsWarmBig
+		case v.Warm:
+			pieces = oceanRuinsWarm
+		case v.Large:
+			pieces = oceanRuinsBrickBig
+		default:
+			pieces = oceanRuinsBrick
+		}
+		v.Start = r.NextInt(len(pieces))
+		v.Piece = "underwater_ruin/" + pieces[v.Start]
+		v.Cluster = v.Large && r.NextFloat() <= 0.9
+		return v, nil
+
+	default:
+		return nil, fmt.Errorf("GetVariant not implemented for %v", st)
+	}
+}
+
+// IsZombieVillage 判断 region 内的村庄是否为僵尸村庄。
+// gen 需已通过 ApplySeed 应用世界种子，用于获取村庄位置处的生物群系；
+// 该 region 没有可生成的村庄时返回 false。
+func (f *Finder) IsZombieVillage(gen *Generator, regX, regZ int) (bool, error) {
+	pos, err := f.GetStructurePos(Village, gen.Seed, regX, regZ)
+	if err != nil || pos == nil {
+		return false, err
+	}
+	biome := gen.GetBiomeAt(1, pos.X, 64, pos.Z)
+	v, err := f.GetVariant(Village, gen.Seed, pos.X, pos.Z, biome)
+	if err != nil || v == nil {
+		return false, err
+	}
+	return v.Abandoned, nil
+}